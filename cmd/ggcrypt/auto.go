@@ -0,0 +1,125 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fzipp/gg/crypt/xor"
+	"github.com/fzipp/gg/ggdict"
+)
+
+// fileSignatures are the starts of file types found in ggpack files.
+var fileSignatures = [][]byte{
+	{0x01, 0x02, 0x03, 0x04},                      // GGDictionary
+	{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}, // PNG
+	{0xFF, 0xD8, 0xFF},                            // JPEG
+	[]byte("OggS"),                                // Ogg
+	[]byte("RIFF"),                                // WAV, FMOD bank
+}
+
+// utf8BOM is the UTF-8 byte order mark at the start of text files.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// maxScoredBytes is the number of bytes at the start of the decoded data
+// that are considered for scoring.
+const maxScoredBytes = 4096
+
+// decodeWithAutoKey decodes the input with each known key and returns the
+// output that looks most like a known file type or text. If the outputs
+// of several keys look equally plausible, it returns an error listing them.
+func decodeWithAutoKey(input []byte, opts options) ([]byte, error) {
+	var (
+		best      []byte
+		bestNames []string
+		bestScore = -1.0
+	)
+	for _, name := range xor.KeyNames() {
+		key, ok := xor.LookupKey(name)
+		if !ok {
+			continue
+		}
+		if key.NeedsLoading() {
+			if opts.execFile == "" {
+				continue
+			}
+			loaded, err := xor.Load(key, opts.execFile)
+			if err != nil {
+				continue
+			}
+			key = loaded
+		}
+		output := transformWith(key, input, opts)
+		s := score(output)
+		switch {
+		case s > bestScore:
+			best, bestNames, bestScore = output, []string{name}, s
+		case s == bestScore && !bytes.Equal(output, best):
+			bestNames = append(bestNames, name)
+		}
+	}
+	if best == nil {
+		return nil, errors.New("no key available for decoding")
+	}
+	if len(bestNames) > 1 {
+		return nil, fmt.Errorf("the output of the keys %s is equally plausible, please choose a key with -key", strings.Join(bestNames, ", "))
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "ggcrypt: using key %q\n", bestNames[0])
+	return best, nil
+}
+
+// score rates how plausible decoded data is: 3 for a GGDict that can be
+// decoded as a whole, 2 plus the ratio of text characters after the byte
+// order mark for text starting with one, 2 for other data starting with a
+// known file signature, otherwise the ratio of text characters.
+func score(data []byte) float64 {
+	if isGGDict(data) {
+		return 3
+	}
+	if bytes.HasPrefix(data, utf8BOM) {
+		return 2 + textRatio(data[len(utf8BOM):])
+	}
+	for _, sig := range fileSignatures {
+		if bytes.HasPrefix(data, sig) {
+			return 2
+		}
+	}
+	return textRatio(data)
+}
+
+// isGGDict reports whether the data can be unmarshalled as a GGDict.
+func isGGDict(data []byte) bool {
+	f, err := ggdict.DetectFormat(data)
+	if err != nil {
+		return false
+	}
+	_, err = ggdict.Unmarshal(data, f)
+	return err == nil
+}
+
+// textRatio returns the ratio of text characters within the first
+// maxScoredBytes bytes of the data.
+func textRatio(data []byte) float64 {
+	if len(data) > maxScoredBytes {
+		data = data[:maxScoredBytes]
+	}
+	if len(data) == 0 {
+		return 0
+	}
+	text := 0
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		if r != utf8.RuneError && (r >= ' ' || r == '\n' || r == '\r' || r == '\t') {
+			text += size
+		}
+		i += size
+	}
+	return float64(text) / float64(len(data))
+}
@@ -0,0 +1,326 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// A tool to decode and encode single files with the ciphers of the games,
+// e.g. files ripped from a ggpack file or debug dumps.
+//
+// Usage:
+//
+//	ggcrypt -decode|-encode [-cipher names] [-key name] [-key-file path]
+//	        [-exec path] [-size n] [-xxtea-key hex] [-o output_file] [input_file]
+//
+// The input is read from the given file or, if no file or "-" is given,
+// from standard input. The output is written to standard output unless
+// an output file is specified.
+//
+// Flags:
+//
+//	-decode     Decode the input.
+//	-encode     Encode the input.
+//	-cipher     Comma-separated list of the ciphers to apply, in the order
+//	            of decoding (default "xor"):
+//	                xor    XOR encryption of files in ggpack files
+//	                bnut   additional encryption of .bnut script files
+//	                xxtea  XXTEA encryption of savegame files
+//	            .bnut files in ggpack files are encrypted with "xor,bnut".
+//	            xxtea cannot be combined with other ciphers.
+//	-key        Name of the XOR key (default "thimbleweed"), see ggpack
+//	            for the supported keys. When decoding, "auto" tries all
+//	            known keys and chooses the key whose output looks most
+//	            like a known file type or text. The key is only used
+//	            by the xor cipher.
+//	-key-file   Path of a JSON file with additional XOR key definitions,
+//	            see ggpack for the format.
+//	-exec       Path of the game's executable file, from which keys like
+//	            Return to Monkey Island's key are loaded.
+//	-size       Size of the original file, if the input is only its
+//	            beginning (default: size of the input).
+//	-xxtea-key  XXTEA key as 32 hexadecimal digits
+//	            (default: key of Thimbleweed Park savegames).
+//	-o          Path of the output file.
+//
+// Examples:
+//
+//	ggcrypt -decode -o Opening.wimpy Opening.wimpy.enc
+//	ggcrypt -decode -key auto < Dump.bin > Dump.dec
+//	ggcrypt -decode -cipher xor,bnut -key delores Boot.bnut > Boot.nut
+//	ggcrypt -decode -key monkey -exec "Return to Monkey Island.exe" Weird.dink
+//	ggcrypt -encode -cipher xor,bnut Boot.nut > Boot.bnut
+//	ggcrypt -decode -cipher xxtea Savegame1.save > Savegame1.dict
+package main
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fzipp/gg/crypt/bnut"
+	"github.com/fzipp/gg/crypt/transform"
+	"github.com/fzipp/gg/crypt/xor"
+	"github.com/fzipp/gg/crypt/xxtea"
+	"github.com/fzipp/gg/savegame"
+)
+
+func usage() {
+	fail(`A tool to decode and encode single files with the ciphers of the games,
+e.g. files ripped from a ggpack file or debug dumps.
+
+Usage:
+    ggcrypt -decode|-encode [-cipher names] [-key name] [-key-file path]
+            [-exec path] [-size n] [-xxtea-key hex] [-o output_file] [input_file]
+
+The input is read from the given file or, if no file or "-" is given,
+from standard input. The output is written to standard output unless
+an output file is specified.
+
+Flags:
+    -decode     Decode the input.
+    -encode     Encode the input.
+    -cipher     Comma-separated list of the ciphers to apply, in the order
+                of decoding (default "xor"):
+                    xor    XOR encryption of files in ggpack files
+                    bnut   additional encryption of .bnut script files
+                    xxtea  XXTEA encryption of savegame files
+                .bnut files in ggpack files are encrypted with "xor,bnut".
+                xxtea cannot be combined with other ciphers.
+    -key        Name of the XOR key (default "thimbleweed"), see ggpack
+                for the supported keys. When decoding, "auto" tries all
+                known keys and chooses the key whose output looks most
+                like a known file type or text. The key is only used
+                by the xor cipher.
+    -key-file   Path of a JSON file with additional XOR key definitions,
+                see ggpack for the format.
+    -exec       Path of the game's executable file, from which keys like
+                Return to Monkey Island's key are loaded.
+    -size       Size of the original file, if the input is only its
+                beginning (default: size of the input).
+    -xxtea-key  XXTEA key as 32 hexadecimal digits
+                (default: key of Thimbleweed Park savegames).
+    -o          Path of the output file.
+
+Examples:
+    ggcrypt -decode -o Opening.wimpy Opening.wimpy.enc
+    ggcrypt -decode -key auto < Dump.bin > Dump.dec
+    ggcrypt -decode -cipher xor,bnut -key delores Boot.bnut > Boot.nut
+    ggcrypt -decode -key monkey -exec "Return to Monkey Island.exe" Weird.dink
+    ggcrypt -encode -cipher xor,bnut Boot.nut > Boot.bnut
+    ggcrypt -decode -cipher xxtea Savegame1.save > Savegame1.dict`)
+}
+
+var seeHelp = "See -help for more information."
+
+const autoKey = "auto"
+
+var supportedCiphers = map[string]bool{
+	"xor":   true,
+	"bnut":  true,
+	"xxtea": true,
+}
+
+type options struct {
+	decode   bool
+	ciphers  []string
+	keyName  string
+	execFile string
+	size     int64
+	xxteaKey xxtea.Key
+}
+
+func main() {
+	decode := flag.Bool("decode", false, "")
+	encode := flag.Bool("encode", false, "")
+	cipherNames := flag.String("cipher", "xor", "")
+	keyName := flag.String("key", "thimbleweed", "")
+	keyFile := flag.String("key-file", "", "")
+	execFile := flag.String("exec", "", "")
+	size := flag.Int64("size", -1, "")
+	xxteaKeyHex := flag.String("xxtea-key", "", "")
+	outputFile := flag.String("o", "", "")
+
+	flag.Usage = usage
+	flag.Parse()
+
+	if !*decode && !*encode {
+		usage()
+	}
+	if *decode && *encode {
+		fail("Please use only one operation flag, not multiple at the same time. " + seeHelp)
+	}
+	if flag.NArg() > 1 {
+		fail("Please specify at most one input file. " + seeHelp)
+	}
+	ciphers := strings.Split(*cipherNames, ",")
+	for _, name := range ciphers {
+		if !supportedCiphers[name] {
+			fail(`Unknown cipher: "` + name + `". ` + seeHelp)
+		}
+	}
+	opts := options{
+		decode:   *decode,
+		ciphers:  ciphers,
+		keyName:  *keyName,
+		execFile: *execFile,
+		size:     *size,
+		xxteaKey: savegame.XXTEAKey,
+	}
+	if *keyFile != "" {
+		names, err := xor.RegisterKeyFile(*keyFile)
+		check(err)
+		if len(names) == 1 && !isFlagSet("key") {
+			opts.keyName = names[0]
+		}
+	}
+	if *xxteaKeyHex != "" {
+		k, err := parseXXTEAKey(*xxteaKeyHex)
+		check(err)
+		opts.xxteaKey = k
+	}
+
+	input, err := readInput(flag.Arg(0))
+	check(err)
+	if opts.size < 0 {
+		opts.size = int64(len(input))
+	}
+	output, err := run(input, opts)
+	check(err)
+	check(writeOutput(*outputFile, output))
+}
+
+func run(input []byte, opts options) ([]byte, error) {
+	for _, name := range opts.ciphers {
+		switch name {
+		case "xor", "bnut":
+		case "xxtea":
+			if len(opts.ciphers) > 1 {
+				return nil, fmt.Errorf("xxtea cannot be combined with other ciphers")
+			}
+			output := append([]byte(nil), input...)
+			if opts.decode {
+				xxtea.DecryptInPlace(output, opts.xxteaKey)
+			} else {
+				xxtea.EncryptInPlace(output, opts.xxteaKey)
+			}
+			return output, nil
+		default:
+			return nil, fmt.Errorf("unknown cipher: %q", name)
+		}
+	}
+	if opts.keyName == autoKey && usesCipher(opts.ciphers, "xor") {
+		if !opts.decode {
+			return nil, fmt.Errorf("key %q can only be used for decoding", autoKey)
+		}
+		return decodeWithAutoKey(input, opts)
+	}
+	var key xor.Key
+	if usesCipher(opts.ciphers, "xor") {
+		var err error
+		key, err = loadKey(opts.keyName, opts.execFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return transformWith(key, input, opts), nil
+}
+
+func usesCipher(ciphers []string, name string) bool {
+	for _, c := range ciphers {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+func loadKey(name, execFile string) (xor.Key, error) {
+	key, ok := xor.LookupKey(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown key name: %q", name)
+	}
+	if !key.NeedsLoading() {
+		return key, nil
+	}
+	if execFile == "" {
+		return nil, fmt.Errorf("key %q needs to be loaded from the game's executable file, please specify it via -exec", name)
+	}
+	return xor.Load(key, execFile)
+}
+
+// transformWith applies the ciphers with the XOR key to the input. When
+// decoding, they are applied in the order given, when encoding in reverse.
+func transformWith(key xor.Key, input []byte, opts options) []byte {
+	var ts []transform.Transformer
+	for _, name := range opts.ciphers {
+		ts = append(ts, newTransformer(name, key, opts))
+	}
+	if !opts.decode {
+		for i, j := 0, len(ts)-1; i < j; i, j = i+1, j-1 {
+			ts[i], ts[j] = ts[j], ts[i]
+		}
+	}
+	output := make([]byte, len(input))
+	transform.Chain(ts...).Transform(output, input)
+	return output
+}
+
+func newTransformer(cipher string, key xor.Key, opts options) transform.Transformer {
+	if cipher == "bnut" {
+		return bnut.NewTransformer(opts.size)
+	}
+	if opts.decode {
+		return key.NewDecoder(opts.size)
+	}
+	return key.NewEncoder(opts.size)
+}
+
+func readInput(path string) ([]byte, error) {
+	if path == "" || path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+func writeOutput(path string, data []byte) error {
+	if path == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func parseXXTEAKey(s string) (xxtea.Key, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != 16 {
+		return xxtea.Key{}, fmt.Errorf("invalid XXTEA key %q: must be 32 hexadecimal digits", s)
+	}
+	var k xxtea.Key
+	for i := range k {
+		k[i] = binary.BigEndian.Uint32(b[i*4:])
+	}
+	return k, nil
+}
+
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func check(err error) {
+	if err != nil {
+		fail(err)
+	}
+}
+
+func fail(message any) {
+	_, _ = fmt.Fprintln(os.Stderr, message)
+	os.Exit(1)
+}
@@ -0,0 +1,38 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fzipp/gg/ggdict"
+)
+
+func diff(pathA, pathB string, f *ggdict.Format) {
+	a, _ := load(pathA, f)
+	b, _ := load(pathB, f)
+	for _, c := range ggdict.Diff(a, b) {
+		fmt.Println(c)
+	}
+}
+
+// merge performs a three-way merge and writes the result to the file of
+// our version, as expected from a git merge driver. If there are
+// conflicts, our values are kept, the conflicts are reported on standard
+// error and the exit status is 1.
+func merge(basePath, oursPath, theirsPath string, f *ggdict.Format) {
+	base, _ := load(basePath, f)
+	ours, format := load(oursPath, f)
+	theirs, _ := load(theirsPath, f)
+	merged, conflicts := ggdict.Merge(base, ours, theirs)
+	check(os.WriteFile(oursPath, marshal(merged, format), 0o644))
+	if len(conflicts) > 0 {
+		for _, c := range conflicts {
+			_, _ = fmt.Fprintln(os.Stderr, "conflict:", c)
+		}
+		fail(fmt.Sprintf("%d merge conflict(s), our values were kept", len(conflicts)))
+	}
+}
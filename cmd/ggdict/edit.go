@@ -0,0 +1,106 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/fzipp/gg/ggdict"
+)
+
+func getValue(path string, f *ggdict.Format, expr string) {
+	dict, _ := load(path, f)
+	p, err := ggdict.ParsePath(expr)
+	check(err)
+	v, err := ggdict.Get(dict, p)
+	check(err)
+	text, err := ggdict.MarshalTextValue(v)
+	check(err)
+	fmt.Println(string(text))
+}
+
+func setValue(path string, f *ggdict.Format, assignment string) {
+	dict, format := load(path, f)
+	p, v, err := ggdict.ParseAssignment(assignment)
+	check(err)
+	check(ggdict.Set(dict, p, v))
+	write(dict, format)
+}
+
+func deleteValue(path string, f *ggdict.Format, expr string) {
+	dict, format := load(path, f)
+	p, err := ggdict.ParsePath(expr)
+	check(err)
+	check(ggdict.Delete(dict, p))
+	write(dict, format)
+}
+
+func applyPatch(path string, f *ggdict.Format, patchFilePath string) {
+	dict, format := load(path, f)
+	patchData, err := os.ReadFile(patchFilePath)
+	check(err)
+	patch, err := unmarshalPatch(patchData)
+	check(err)
+	dict, err = patch.Apply(dict)
+	check(err)
+	write(dict, format)
+}
+
+func unmarshalPatch(data []byte) (ggdict.Patch, error) {
+	var patch ggdict.Patch
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, fmt.Errorf("could not decode JSON patch: %w", err)
+	}
+	return patch, nil
+}
+
+// load reads a GGDictionary file in the given format, or in the detected
+// format if f is nil, and returns the dictionary and the format it was
+// read with.
+func load(path string, f *ggdict.Format) (map[string]any, ggdict.Format) {
+	buf, format := readFile(path, f)
+	dict, err := ggdict.Unmarshal(buf, format)
+	check(err)
+	return dict, format
+}
+
+// readFile reads a GGDictionary file and returns its data and its format,
+// which is detected if f is nil.
+func readFile(path string, f *ggdict.Format) ([]byte, ggdict.Format) {
+	buf, err := os.ReadFile(path)
+	check(err)
+	format := outputFormat(f)
+	if f == nil {
+		format, err = ggdict.DetectFormat(buf)
+		check(err)
+	}
+	return buf, format
+}
+
+// outputFormat returns the format for writing a GGDictionary that was not
+// read from a GGDictionary file. If no format was given, it defaults to
+// the Thimbleweed Park format.
+func outputFormat(f *ggdict.Format) ggdict.Format {
+	if f == nil {
+		return ggdict.FormatThimbleweed
+	}
+	return *f
+}
+
+func write(dict map[string]any, f ggdict.Format) {
+	_, err := os.Stdout.Write(marshal(dict, f))
+	check(err)
+}
+
+// marshalOptions are the options for writing GGDictionary files.
+var marshalOptions ggdict.MarshalOptions
+
+func marshal(dict map[string]any, f ggdict.Format) []byte {
+	data, err := marshalOptions.Marshal(dict, f)
+	check(err)
+	return data
+}
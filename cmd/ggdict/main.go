@@ -2,7 +2,8 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// A tool to convert back and forth between the GGDictionary format and JSON.
+// A tool to convert back and forth between the GGDictionary format and JSON
+// or a typed text format.
 //
 // The GGDictionary format is used by the Thimbleweed Park point-and-click
 // adventure game engine. It encodes a key-value data structure like JSON, but as
@@ -11,31 +12,109 @@
 //
 // Usage:
 //
-//	ggdict [-format name] -to-json|-from-json path
+//	ggdict [-format name] [-sort-strings] [-typed] [-keep-order] -to-json|-from-json path
+//	ggdict [-format name] [-sort-strings] -to-text|-from-text path
+//	ggdict [-format name] [-sort-strings] -get path_expr|-set path_expr=value|-delete path_expr path
+//	ggdict [-format name] [-sort-strings] -patch patch_file path
+//	ggdict [-format name] -diff path_a path_b
+//	ggdict [-format name] [-sort-strings] -merge base_path ours_path theirs_path
+//	ggdict [-format name] -validate -schema name path
+//	ggdict [-format name] -stats path
 //
 // Flags:
 //
 //	-format     Supported formats are:
-//	                thimbleweed  Thimbleweed Park / Delores (default)
+//	                auto         Detects the format of GGDictionary files
+//	                             (default). Files converted to
+//	                             GGDictionary format are written in
+//	                             Thimbleweed Park format.
+//	                thimbleweed  Thimbleweed Park / Delores
 //	                monkey       Return to Monkey Island
 //	-to-json    Converts the given GGDictionary file to JSON format on
-//	            standard output.
+//	            standard output. Floats are always written with a decimal
+//	            point or an exponent, so that they stay floats when converted
+//	            back with -from-json.
+//	-typed      Annotates coordinate values in the JSON output of -to-json
+//	            with their type, e.g.
+//	                {"$type": "coordinate", "$value": "{213,118}"}
+//	            so that they keep their type when converted back with
+//	            -from-json -typed. With -from-json, it decodes such type
+//	            annotations, which are kept as dictionaries otherwise.
+//	-keep-order Keeps the order of dictionary keys in the output of
+//	            -to-json and -from-json instead of sorting them.
 //	-from-json  Converts the given JSON file to GGDictionary format on
 //	            standard output. You might want to redirect it to a file,
-//	            since it is a binary format.
+//	            since it is a binary format. Numbers with a decimal point
+//	            or an exponent are written as floats, all other numbers
+//	            as integers.
+//	-to-text    Converts the given GGDictionary file to the text format on
+//	            standard output. Unlike JSON, the text format keeps the
+//	            distinction between integers, floats and coordinates, and
+//	            it allows comments.
+//	-from-text  Converts the given text file to GGDictionary format on
+//	            standard output.
+//	-get        Prints the value at the given path expression, e.g.
+//	            'objects[3].hotspot', in the text format.
+//	-set        Sets the value at the given path expression and writes the
+//	            modified GGDictionary to standard output. The value is given
+//	            in the text format, e.g. 'objects[3].zsort=5' or
+//	            'objects[3].name="door"'.
+//	-delete     Removes the value at the given path expression and writes
+//	            the modified GGDictionary to standard output.
+//	-patch      Applies the operations of the given JSON Patch (RFC 6902)
+//	            file and writes the modified GGDictionary to standard output.
+//	-diff       Prints the differences between two GGDictionary files,
+//	            one line per changed path, e.g.
+//	                ~ objects[3].zsort: 4 -> 5
+//	            Added values are prefixed with '+', removed values with '-'.
+//	-merge      Merges the changes of two GGDictionary files, ours and
+//	            theirs, that were derived from a common base, and writes the
+//	            result to the ours file. If both sides changed a value in
+//	            different ways, our value is kept, the conflict is reported
+//	            and the exit status is 1. This is the behavior expected from
+//	            a git merge driver.
+//	-validate   Checks the given GGDictionary file against the schema
+//	            given with -schema and reports missing required keys, values
+//	            of the wrong type and unknown keys.
+//	-schema     Supported schemas are:
+//	                wimpy      Room (*.wimpy) files
+//	                animation  Animation (*Animation.json) files
+//	                directory  The directory of a ggpack file
+//	                savegame   Decrypted savegames
+//	-stats      Prints statistics about the given GGDictionary file: the
+//	            size of the string table, the bytes taken up by numbers,
+//	            which are stored as strings, the number of values by type,
+//	            the maximum nesting depth and the most referenced strings.
+//	-sort-strings
+//	            Orders the string table of written GGDictionary files by the
+//	            number of references to each string. With the 16-bit string
+//	            indices of the monkey format this keeps the most frequently
+//	            used strings within the index range.
 //
 // Examples:
 //
 //	ggdict -to-json Example.wimpy > Example.wimpy.json
 //	ggdict -from-json Example.wimpy.json > Example.wimpy
 //	ggdict -to-json ExampleAnimation.json > ExampleAnimation.really.json
+//	ggdict -typed -to-json Example.wimpy > Example.wimpy.json
+//	ggdict -to-text Example.wimpy > Example.wimpy.txt
+//	ggdict -from-text Example.wimpy.txt > Example.wimpy
+//	ggdict -get 'objects[3].hotspot' Example.wimpy
+//	ggdict -set 'objects[3].zsort=5' Example.wimpy > Example.modified.wimpy
+//	ggdict -patch changes.json Example.wimpy > Example.modified.wimpy
+//	ggdict -diff Example.wimpy Example.modified.wimpy
+//	ggdict -validate -schema wimpy Example.wimpy
+//	ggdict -stats Example.wimpy
 //
-//	ggdict -format monkey -to-json Example.wimpy > Example.wimpy.json
 //	ggdict -format monkey -from-json Example.wimpy.json > Example.wimpy
+//
+// To merge GGDictionary files with git, configure a merge driver:
+//
+//	git config merge.ggdict.driver 'ggdict -merge %O %A %B'
+//	echo '*.wimpy merge=ggdict' >> .gitattributes
 package main
 
 import (
-	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
@@ -45,89 +124,282 @@ import (
 )
 
 func usage() {
-	fail(`A tool to convert back and forth between the GGDictionary format and JSON.
+	fail(`A tool to convert back and forth between the GGDictionary format and JSON
+or a typed text format.
 
 The GGDictionary format encodes a key-value data structure like JSON, but as
 a binary format. For example, for Thimbleweed Park *.wimpy and *Animation.json
 files are stored in this format within a "ggpack" file.
 
 Usage:
-    ggdict [-format name] -to-json|-from-json path
+    ggdict [-format name] [-sort-strings] [-typed] [-keep-order] -to-json|-from-json path
+    ggdict [-format name] [-sort-strings] -to-text|-from-text path
+    ggdict [-format name] [-sort-strings] -get path_expr|-set path_expr=value|-delete path_expr path
+    ggdict [-format name] [-sort-strings] -patch patch_file path
+    ggdict [-format name] -diff path_a path_b
+    ggdict [-format name] [-sort-strings] -merge base_path ours_path theirs_path
+    ggdict [-format name] -validate -schema name path
+    ggdict [-format name] -stats path
 
 Flags:
     -format     Supported formats are:
-                    thimbleweed  Thimbleweed Park / Delores (default)
+                    auto         Detects the format of GGDictionary files
+                                 (default). Files converted to
+                                 GGDictionary format are written in
+                                 Thimbleweed Park format.
+                    thimbleweed  Thimbleweed Park / Delores
                     monkey       Return to Monkey Island
     -to-json    Converts the given GGDictionary file to JSON format on
-                standard output.
+                standard output. Floats are always written with a decimal
+                point or an exponent, so that they stay floats when converted
+                back with -from-json.
+    -typed      Annotates coordinate values in the JSON output of -to-json
+                with their type, e.g.
+                    {"$type": "coordinate", "$value": "{213,118}"}
+                so that they keep their type when converted back with
+                -from-json -typed. With -from-json, it decodes such type
+                annotations, which are kept as dictionaries otherwise.
+    -keep-order Keeps the order of dictionary keys in the output of
+                -to-json and -from-json instead of sorting them.
     -from-json  Converts the given JSON file to GGDictionary format on
                 standard output. You might want to redirect it to a file,
-                since it is a binary format.
+                since it is a binary format. Numbers with a decimal point
+                or an exponent are written as floats, all other numbers
+                as integers.
+    -to-text    Converts the given GGDictionary file to the text format on
+                standard output. Unlike JSON, the text format keeps the
+                distinction between integers, floats and coordinates, and
+                it allows comments.
+    -from-text  Converts the given text file to GGDictionary format on
+                standard output.
+    -get        Prints the value at the given path expression, e.g.
+                'objects[3].hotspot', in the text format.
+    -set        Sets the value at the given path expression and writes the
+                modified GGDictionary to standard output. The value is given
+                in the text format, e.g. 'objects[3].zsort=5' or
+                'objects[3].name="door"'.
+    -delete     Removes the value at the given path expression and writes
+                the modified GGDictionary to standard output.
+    -patch      Applies the operations of the given JSON Patch (RFC 6902)
+                file and writes the modified GGDictionary to standard output.
+    -diff       Prints the differences between two GGDictionary files,
+                one line per changed path, e.g.
+                    ~ objects[3].zsort: 4 -> 5
+                Added values are prefixed with '+', removed values with '-'.
+    -merge      Merges the changes of two GGDictionary files, ours and
+                theirs, that were derived from a common base, and writes the
+                result to the ours file. If both sides changed a value in
+                different ways, our value is kept, the conflict is reported
+                and the exit status is 1. This is the behavior expected from
+                a git merge driver.
+    -validate   Checks the given GGDictionary file against the schema
+                given with -schema and reports missing required keys, values
+                of the wrong type and unknown keys.
+    -schema     Supported schemas are:
+                    wimpy      Room (*.wimpy) files
+                    animation  Animation (*Animation.json) files
+                    directory  The directory of a ggpack file
+                    savegame   Decrypted savegames
+    -stats      Prints statistics about the given GGDictionary file: the
+                size of the string table, the bytes taken up by numbers,
+                which are stored as strings, the number of values by type,
+                the maximum nesting depth and the most referenced strings.
+    -sort-strings
+                Orders the string table of written GGDictionary files by the
+                number of references to each string. With the 16-bit string
+                indices of the monkey format this keeps the most frequently
+                used strings within the index range.
 
 Examples:
     ggdict -to-json Example.wimpy > Example.wimpy.json
     ggdict -from-json Example.wimpy.json > Example.wimpy
     ggdict -to-json ExampleAnimation.json > ExampleAnimation.really.json
+    ggdict -typed -to-json Example.wimpy > Example.wimpy.json
+    ggdict -to-text Example.wimpy > Example.wimpy.txt
+    ggdict -from-text Example.wimpy.txt > Example.wimpy
+    ggdict -get 'objects[3].hotspot' Example.wimpy
+    ggdict -set 'objects[3].zsort=5' Example.wimpy > Example.modified.wimpy
+    ggdict -patch changes.json Example.wimpy > Example.modified.wimpy
+    ggdict -diff Example.wimpy Example.modified.wimpy
+    ggdict -validate -schema wimpy Example.wimpy
+    ggdict -stats Example.wimpy
+
+    ggdict -format monkey -from-json Example.wimpy.json > Example.wimpy
 
-    ggdict -format monkey -to-json Example.wimpy > Example.wimpy.json
-    ggdict -format monkey -from-json Example.wimpy.json > Example.wimpy`)
+To merge GGDictionary files with git, configure a merge driver:
+
+    git config merge.ggdict.driver 'ggdict -merge %O %A %B'
+    echo '*.wimpy merge=ggdict' >> .gitattributes`)
 }
 
 var seeHelp = "See -help for more information."
 
-var supportedFormats = map[string]ggdict.Format{
-	"thimbleweed": ggdict.FormatThimbleweed,
-	"monkey":      ggdict.FormatMonkey,
+// supportedFormats maps the format names to formats. A nil format means
+// that the format is detected automatically.
+var supportedFormats = map[string]*ggdict.Format{
+	"auto":        nil,
+	"thimbleweed": &ggdict.FormatThimbleweed,
+	"monkey":      &ggdict.FormatMonkey,
 }
 
 func main() {
-	formatName := flag.String("format", "thimbleweed", "")
+	formatName := flag.String("format", "auto", "")
 	ggdictFilePath := flag.String("to-json", "", "")
 	jsonFilePath := flag.String("from-json", "", "")
+	ggdictTextFilePath := flag.String("to-text", "", "")
+	textFilePath := flag.String("from-text", "", "")
+	typed := flag.Bool("typed", false, "")
+	keepOrder := flag.Bool("keep-order", false, "")
+	getExpr := flag.String("get", "", "")
+	setExpr := flag.String("set", "", "")
+	deleteExpr := flag.String("delete", "", "")
+	patchFilePath := flag.String("patch", "", "")
+	diffFlag := flag.Bool("diff", false, "")
+	mergeFlag := flag.Bool("merge", false, "")
+	validateFlag := flag.Bool("validate", false, "")
+	schemaName := flag.String("schema", "", "")
+	statsFilePath := flag.String("stats", "", "")
+	sortStrings := flag.Bool("sort-strings", false, "")
 
 	flag.Usage = usage
 	flag.Parse()
 
-	if *ggdictFilePath == "" && *jsonFilePath == "" {
+	operations := 0
+	for _, op := range []string{
+		*ggdictFilePath, *jsonFilePath, *ggdictTextFilePath, *textFilePath,
+		*getExpr, *setExpr, *deleteExpr, *patchFilePath, *statsFilePath,
+	} {
+		if op != "" {
+			operations++
+		}
+	}
+	for _, op := range []bool{*diffFlag, *mergeFlag, *validateFlag} {
+		if op {
+			operations++
+		}
+	}
+	if operations == 0 {
 		usage()
 	}
-	if *ggdictFilePath != "" && *jsonFilePath != "" {
-		fail("-from-json and -to-json flags cannot be used together. " + seeHelp)
+	if operations > 1 {
+		fail("Please use only one operation flag, not multiple at the same time. " + seeHelp)
 	}
 	format, ok := supportedFormats[strings.ToLower(*formatName)]
 	if !ok {
 		fail(`Unknown format: "` + *formatName + `". ` + seeHelp)
 	}
 
+	marshalOptions.FrequencySortedStrings = *sortStrings
+
+	jsonOptions := ggdict.JSONOptions{
+		Indent:           "  ",
+		AnnotateTypes:    *typed,
+		PreserveNumbers:  true,
+		OriginalKeyOrder: *keepOrder,
+	}
+
 	if *ggdictFilePath != "" {
-		toJSON(*ggdictFilePath, format)
+		toJSON(*ggdictFilePath, format, jsonOptions)
 		return
 	}
 
 	if *jsonFilePath != "" {
-		fromJSON(*jsonFilePath, format)
+		fromJSON(*jsonFilePath, format, jsonOptions)
+		return
+	}
+
+	if *ggdictTextFilePath != "" {
+		toText(*ggdictTextFilePath, format)
+		return
+	}
+
+	if *textFilePath != "" {
+		fromText(*textFilePath, format)
+		return
+	}
+
+	if *statsFilePath != "" {
+		printStats(*statsFilePath, format)
+		return
+	}
+
+	if *diffFlag {
+		if flag.NArg() != 2 {
+			fail("Please specify exactly two GGDictionary file arguments to compare. " + seeHelp)
+		}
+		diff(flag.Arg(0), flag.Arg(1), format)
 		return
 	}
+
+	if *mergeFlag {
+		if flag.NArg() != 3 {
+			fail("Please specify exactly three GGDictionary file arguments: base, ours and theirs. " + seeHelp)
+		}
+		merge(flag.Arg(0), flag.Arg(1), flag.Arg(2), format)
+		return
+	}
+
+	if flag.NArg() != 1 {
+		fail("Please specify exactly one GGDictionary file argument. " + seeHelp)
+	}
+	path := flag.Arg(0)
+
+	if *getExpr != "" {
+		getValue(path, format, *getExpr)
+		return
+	}
+
+	if *setExpr != "" {
+		setValue(path, format, *setExpr)
+		return
+	}
+
+	if *deleteExpr != "" {
+		deleteValue(path, format, *deleteExpr)
+		return
+	}
+
+	if *patchFilePath != "" {
+		applyPatch(path, format, *patchFilePath)
+		return
+	}
+
+	if *validateFlag {
+		validate(path, format, *schemaName)
+		return
+	}
+}
+
+func toJSON(path string, f *ggdict.Format, opts ggdict.JSONOptions) {
+	buf, format := readFile(path, f)
+	check(ggdict.ToJSON(os.Stdout, buf, format, opts))
 }
 
-func toJSON(path string, f ggdict.Format) {
-	buf, err := os.ReadFile(path)
+func fromJSON(path string, f *ggdict.Format, opts ggdict.JSONOptions) {
+	jsonFile, err := os.Open(path)
 	check(err)
-	dict, err := ggdict.Unmarshal(buf, f)
+	defer jsonFile.Close()
+	opts.EncodeOptions = marshalOptions
+	data, err := ggdict.FromJSON(jsonFile, outputFormat(f), opts)
 	check(err)
-	jsonData, err := json.MarshalIndent(dict, "", "  ")
+	_, err = os.Stdout.Write(data)
 	check(err)
-	fmt.Println(string(jsonData))
 }
 
-func fromJSON(path string, f ggdict.Format) {
-	jsonData, err := os.ReadFile(path)
+func toText(path string, f *ggdict.Format) {
+	dict, _ := load(path, f)
+	text, err := ggdict.MarshalText(dict)
 	check(err)
-	dict := make(map[string]any)
-	err = json.Unmarshal(jsonData, &dict)
+	_, err = os.Stdout.Write(text)
+	check(err)
+}
+
+func fromText(path string, f *ggdict.Format) {
+	text, err := os.ReadFile(path)
 	check(err)
-	_, err = os.Stdout.Write(ggdict.Marshal(dict, f))
+	dict, err := ggdict.UnmarshalText(text)
 	check(err)
+	write(dict, outputFormat(f))
 }
 
 func check(err error) {
@@ -0,0 +1,51 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/fzipp/gg/ggdict"
+)
+
+// maxStatsStrings is the maximum number of the most referenced strings
+// that are printed by -stats.
+const maxStatsStrings = 20
+
+func printStats(path string, f *ggdict.Format) {
+	buf, format := readFile(path, f)
+	stats, err := ggdict.Analyze(buf, format)
+	check(err)
+
+	fmt.Printf("Size:               %d bytes\n", stats.Size)
+	fmt.Printf("String table:       %d bytes (%.1f%%), %d strings\n",
+		stats.StringTableSize, percent(stats.StringTableSize, stats.Size), len(stats.Strings))
+	fmt.Printf("Numbers as strings: %d bytes (%.1f%%), %d strings\n",
+		stats.NumberStringBytes, percent(stats.NumberStringBytes, stats.Size), stats.NumberStrings)
+	fmt.Printf("Maximum depth:      %d\n", stats.MaxDepth)
+	fmt.Println()
+	fmt.Println("Values:")
+	for kind := ggdict.KindNull; kind <= ggdict.KindCoordinateList; kind++ {
+		if n := stats.Values[kind]; n > 0 {
+			fmt.Printf("    %-16s %8d\n", kind, n)
+		}
+	}
+	fmt.Println()
+	fmt.Println("Most referenced strings:")
+	for i, s := range stats.Strings {
+		if i == maxStatsStrings {
+			break
+		}
+		fmt.Printf("    %8d  %s\n", s.Count, strconv.Quote(s.Value))
+	}
+}
+
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(part) / float64(total)
+}
@@ -0,0 +1,37 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fzipp/gg/ggdict"
+)
+
+// validate checks a GGDictionary file against a known schema and reports
+// all violations on standard error, one per line.
+func validate(path string, f *ggdict.Format, schemaName string) {
+	if schemaName == "" {
+		fail("Please specify a schema with -schema. " + seeHelp)
+	}
+	schema, ok := ggdict.SchemaByName(strings.ToLower(schemaName))
+	if !ok {
+		fail(`Unknown schema: "` + schemaName + `". ` + seeHelp)
+	}
+	dict, _ := load(path, f)
+	err := schema.Validate(dict)
+	var errs ggdict.ValidationErrors
+	if !errors.As(err, &errs) {
+		check(err)
+		return
+	}
+	for _, e := range errs {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %s\n", path, e)
+	}
+	os.Exit(1)
+}
@@ -6,33 +6,63 @@
 //
 // Usage:
 //
-//	ggpack -list|-extract|-create "filename_pattern" [-key name] ggpack_file
+//	ggpack -list|-extract|-create "filename_pattern" [-key name] [-key-file path] ggpack_file
+//	ggpack -recover-key ggpack_file
 //
 // Flags:
 //
-//	-list     List files in the pack matching the pattern.
-//	-extract  Extract the files from the pack matching the pattern to
-//	          the current working directory.
-//	-create   Create a new pack and add the files from the file system
-//	          matching the pattern.
-//	-key      Name of the key to decrypt/encrypt the data via XOR.
-//	          Supported key names:
-//	              twp-56ad    Thimbleweed Park (default)
-//	              twp-5bad    Thimbleweed Park
-//	              twp-566d    Thimbleweed Park
-//	              twp-5b6d    Thimbleweed Park
-//	              delores     Delores
-//	              monkey      Return to Monkey Island
+//	-list      List files in the pack matching the pattern.
+//	-extract   Extract the files from the pack matching the pattern to
+//	           the current working directory.
+//	-create    Create a new pack and add the files from the file system
+//	           matching the pattern.
+//	-key       Name of the key to decrypt/encrypt the data via XOR.
+//	           Supported keys:
+//	               thimbleweed         Thimbleweed Park (default)
+//	               thimbleweed-5bad    Thimbleweed Park
+//	               thimbleweed-566d    Thimbleweed Park
+//	               thimbleweed-5b6d    Thimbleweed Park
+//	               delores             Delores
+//	               monkey              Return to Monkey Island
+//	           and the keys defined in the key file.
+//	-key-file  Path of a JSON file with additional key definitions.
+//	           If the file defines a single key and no -key is specified,
+//	           this key is used.
+//	-recover-key
+//	           Recover the key of a pack encrypted like Thimbleweed Park
+//	           or Delores packs, but with unknown magic bytes or multiplier,
+//	           from the known structure of the pack directory and known
+//	           file headers. The candidates are written to the standard
+//	           output in the key file format.
 //
 //	Note: Return to Monkey Island's key is extracted from the game's
 //	executable which is assumed to be located in the same directory as
 //	the pack file.
 //
+// Key file format:
+//
+//	{
+//	  "mykey": {
+//	    "type": "twp",
+//	    "magicBytes": "4FD0A0AC4A56B9E5937945A5C1CB3193",
+//	    "multiplier": 173,
+//	    "ggdictFormat": "thimbleweed"
+//	  }
+//	}
+//
+//	The type is "twp" (Thimbleweed Park, Delores) or "rtmi" (Return to
+//	Monkey Island). Keys of type "rtmi" have a "modifier" instead of a
+//	multiplier, and their magic bytes ("magicBytes1", "magicBytes2") are
+//	extracted from the game's executable if they are omitted. The
+//	"ggdictFormat" ("thimbleweed" or "monkey") is optional.
+//
 // Examples:
 //
 //	ggpack -list "*" ExamplePackage.ggpack1
 //	ggpack -list "*.tsv" ExamplePackage.ggpack1
 //	ggpack -list "*" -key monkey Weird.ggpack1a
+//	ggpack -list "*" -key-file mykey.json Custom.ggpack1
+//	ggpack -recover-key Custom.ggpack1 > mykey.json
 //	ggpack -extract "ExampleSheet.png" ExamplePackage.ggpack1
 //	ggpack -extract "*.txt" ExamplePackage.ggpack1
 //	ggpack -extract "*" ExamplePackage.ggpack1
@@ -46,7 +76,6 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/fzipp/gg/crypt/xor"
 	"github.com/fzipp/gg/ggpack"
@@ -56,31 +85,60 @@ func usage() {
 	fail(`A tool to inspect, unpack or create "ggpack" files.
 
 Usage:
-    ggpack -list|-extract|-create "filename_pattern" [-key name] ggpack_file
+    ggpack -list|-extract|-create "filename_pattern" [-key name] [-key-file path] ggpack_file
+    ggpack -recover-key ggpack_file
 
 Flags:
-    -list     List files in the pack matching the pattern.
-    -extract  Extract the files from the pack matching the pattern to
-              the current working directory.
-    -create   Create a new pack and add the files from the file system
-              matching the pattern.
-    -key      Name of the key to decrypt/encrypt the data via XOR.
-              Supported keys:
-                  thimbleweed         Thimbleweed Park (default)
-                  thimbleweed-5bad    Thimbleweed Park
-                  thimbleweed-566d    Thimbleweed Park
-                  thimbleweed-5b6d    Thimbleweed Park
-                  delores             Delores
-                  monkey              Return to Monkey Island
-
-              Note: Return to Monkey Island's key is extracted from the game's 
-              executable which is assumed to be located in the same directory as
-              the pack file.
+    -list      List files in the pack matching the pattern.
+    -extract   Extract the files from the pack matching the pattern to
+               the current working directory.
+    -create    Create a new pack and add the files from the file system
+               matching the pattern.
+    -key       Name of the key to decrypt/encrypt the data via XOR.
+               Supported keys:
+                   thimbleweed         Thimbleweed Park (default)
+                   thimbleweed-5bad    Thimbleweed Park
+                   thimbleweed-566d    Thimbleweed Park
+                   thimbleweed-5b6d    Thimbleweed Park
+                   delores             Delores
+                   monkey              Return to Monkey Island
+               and the keys defined in the key file.
+    -key-file  Path of a JSON file with additional key definitions.
+               If the file defines a single key and no -key is specified,
+               this key is used.
+    -recover-key
+               Recover the key of a pack encrypted like Thimbleweed Park
+               or Delores packs, but with unknown magic bytes or multiplier,
+               from the known structure of the pack directory and known
+               file headers. The candidates are written to the standard
+               output in the key file format.
+
+    Note: Return to Monkey Island's key is extracted from the game's
+    executable which is assumed to be located in the same directory as
+    the pack file.
+
+Key file format:
+    {
+      "mykey": {
+        "type": "twp",
+        "magicBytes": "4FD0A0AC4A56B9E5937945A5C1CB3193",
+        "multiplier": 173,
+        "ggdictFormat": "thimbleweed"
+      }
+    }
+
+    The type is "twp" (Thimbleweed Park, Delores) or "rtmi" (Return to
+    Monkey Island). Keys of type "rtmi" have a "modifier" instead of a
+    multiplier, and their magic bytes ("magicBytes1", "magicBytes2") are
+    extracted from the game's executable if they are omitted. The
+    "ggdictFormat" ("thimbleweed" or "monkey") is optional.
 
 Examples:
     ggpack -list "*" ExamplePackage.ggpack1
     ggpack -list "*.tsv" ExamplePackage.ggpack1
     ggpack -list "*" -key monkey Weird.ggpack1a
+    ggpack -list "*" -key-file mykey.json Custom.ggpack1
+    ggpack -recover-key Custom.ggpack1 > mykey.json
     ggpack -extract "ExampleSheet.png" ExamplePackage.ggpack1
     ggpack -extract "*.txt" ExamplePackage.ggpack1
     ggpack -extract "*" ExamplePackage.ggpack1
@@ -94,6 +152,8 @@ func main() {
 	extractPattern := flag.String("extract", "", "Extract the files from the pack matching the pattern to the current working directory.")
 	createPattern := flag.String("create", "", "Create a new pack and add the files from the file system matching the pattern.")
 	keyName := flag.String("key", "thimbleweed", "Name of the key to decrypt/encrypt the data via XOR.")
+	keyFile := flag.String("key-file", "", "Path of a JSON file with additional key definitions.")
+	recoverKey := flag.Bool("recover-key", false, "Recover the key of a pack from known plaintext.")
 
 	flag.Usage = usage
 	flag.Parse()
@@ -109,6 +169,11 @@ func main() {
 	}
 	packFile := flag.Arg(0)
 
+	if *recoverKey {
+		recoverKeys(packFile)
+		return
+	}
+
 	patternFlags := []string{*listPattern, *extractPattern, *createPattern}
 	var patterns []string
 	for _, pattern := range patternFlags {
@@ -128,11 +193,18 @@ func main() {
 	}
 
 	pattern := patterns[0]
-	key, ok := xor.KnownKeys[strings.ToLower(*keyName)]
+	if *keyFile != "" {
+		names, err := xor.RegisterKeyFile(*keyFile)
+		check(err)
+		if len(names) == 1 && !isFlagSet("key") {
+			*keyName = names[0]
+		}
+	}
+	key, ok := xor.LookupKey(*keyName)
 	if !ok {
 		fail(`Unknown key name: "` + *keyName + `". ` + seeHelp)
 	}
-	loadKeyIfNecessary(key, packFile)
+	key = loadKeyIfNecessary(key, packFile)
 
 	if *createPattern != "" {
 		paths, err := filepath.Glob(pattern)
@@ -215,6 +287,16 @@ func create(packFilePath string, paths []string, key xor.Key) error {
 	return packer.Finish()
 }
 
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
 func check(err error) {
 	if err != nil {
 		fail(err)
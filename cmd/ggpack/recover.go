@@ -0,0 +1,32 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/fzipp/gg/crypt/xor"
+	"github.com/fzipp/gg/ggpack"
+)
+
+func recoverKeys(packFile string) {
+	keys, err := ggpack.RecoverKeys(packFile)
+	check(err)
+	keyFile := make(xor.KeyFile, len(keys))
+	for i, key := range keys {
+		multiplier := key.Multiplier
+		keyFile[fmt.Sprintf("recovered-%d", i+1)] = xor.KeyDefinition{
+			Type:       xor.KeyTypeTWP,
+			MagicBytes: key.MagicBytes,
+			Multiplier: &multiplier,
+		}
+	}
+	data, err := json.MarshalIndent(keyFile, "", "  ")
+	check(err)
+	_, err = fmt.Fprintln(os.Stdout, string(data))
+	check(err)
+}
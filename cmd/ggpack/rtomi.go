@@ -12,18 +12,19 @@ import (
 	"github.com/fzipp/gg/crypt/xor"
 )
 
-func loadKeyIfNecessary(key xor.Key, packFile string) {
+func loadKeyIfNecessary(key xor.Key, packFile string) xor.Key {
 	if !key.NeedsLoading() {
-		return
+		return key
 	}
 	execFile, err := locateExecFile(packFile)
 	if err != nil {
 		fail("Could not find game executable file. Please make sure that your pack file is located in the same directory as the game's executable.")
 	}
-	err = key.LoadFrom(execFile)
+	loaded, err := xor.Load(key, execFile)
 	if err != nil {
 		fail("XOR key could not be loaded from the game's executable.")
 	}
+	return loaded
 }
 
 func locateExecFile(packFile string) (string, error) {
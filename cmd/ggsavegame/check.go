@@ -0,0 +1,83 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/fzipp/gg/savegame"
+)
+
+// checkFile prints a diagnosis of a savegame file for each format and
+// exits with status 1 if it cannot be read with any of them.
+func checkFile(path string, f *savegame.Format) {
+	data, err := os.ReadFile(path)
+	check(err)
+	var diagnoses []*savegame.Diagnosis
+	if f != nil {
+		diagnoses = []*savegame.Diagnosis{f.Diagnose(data)}
+	} else {
+		diagnoses = savegame.Diagnose(data)
+	}
+	ok := false
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for i, d := range diagnoses {
+		if i > 0 {
+			_, _ = fmt.Fprintln(w)
+		}
+		printDiagnosis(w, d)
+		ok = ok || d.Ok()
+	}
+	check(w.Flush())
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+func printDiagnosis(w *tabwriter.Writer, d *savegame.Diagnosis) {
+	_, _ = fmt.Fprintf(w, "format:\t%s\n", d.Format.Name)
+	_, _ = fmt.Fprintf(w, "size:\t%d bytes\n", d.Size)
+	if d.Signature == nil {
+		_, _ = fmt.Fprintf(w, "error:\t%v\n", d.DictErr)
+		return
+	}
+	if d.ValidSignature {
+		_, _ = fmt.Fprintf(w, "GGDict signature:\tok (% x)\n", d.Signature)
+	} else {
+		_, _ = fmt.Fprintf(w, "GGDict signature:\tnot found (% x), wrong key: not a %s savegame?\n",
+			d.Signature, d.Format.Name)
+	}
+	_, _ = fmt.Fprintf(w, "stored checksum:\t%#08x\n", d.StoredChecksum)
+	if d.ChecksumOk() {
+		_, _ = fmt.Fprintf(w, "computed checksum:\t%#08x (ok)\n", d.ComputedChecksum)
+	} else if d.ValidSignature {
+		_, _ = fmt.Fprintf(w, "computed checksum:\t%#08x (mismatch, see -repair)\n", d.ComputedChecksum)
+	} else {
+		_, _ = fmt.Fprintf(w, "computed checksum:\t%#08x (mismatch)\n", d.ComputedChecksum)
+	}
+	_, _ = fmt.Fprintf(w, "footer:\t% x\n", d.Footer)
+	if d.DictErr != nil {
+		_, _ = fmt.Fprintf(w, "dictionary:\terror: %v\n", d.DictErr)
+	} else {
+		_, _ = fmt.Fprintf(w, "dictionary:\tok\n")
+	}
+}
+
+// repairFile recomputes the checksum of a savegame file in place and
+// keeps the original file as a backup.
+func repairFile(path string, f *savegame.Format) {
+	data, err := os.ReadFile(path)
+	check(err)
+	var repaired []byte
+	if f != nil {
+		repaired, err = f.Repair(data)
+	} else {
+		repaired, _, err = savegame.Repair(data)
+	}
+	check(err)
+	check(writeWithBackup(path, repaired))
+}
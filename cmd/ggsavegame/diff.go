@@ -0,0 +1,19 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/fzipp/gg/savegame"
+)
+
+func diff(pathA, pathB string, f *savegame.Format) {
+	a, _ := load(pathA, f)
+	b, _ := load(pathB, f)
+	for _, c := range savegame.Diff(a, b) {
+		fmt.Println(c)
+	}
+}
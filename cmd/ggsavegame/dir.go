@@ -0,0 +1,130 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fzipp/gg/ggdict"
+	"github.com/fzipp/gg/savegame"
+)
+
+// index is the summary of the savegames in a save directory.
+type index struct {
+	Dir   string       `json:"dir"`
+	Slots []indexEntry `json:"slots"`
+}
+
+type indexEntry struct {
+	Name          string  `json:"name"`
+	Path          string  `json:"path"`
+	Thumbnail     string  `json:"thumbnail,omitempty"`
+	JSON          string  `json:"json,omitempty"`
+	Valid         bool    `json:"valid"`
+	Error         string  `json:"error,omitempty"`
+	Format        string  `json:"format,omitempty"`
+	SaveTime      string  `json:"saveTime,omitempty"`
+	GameTime      float64 `json:"gameTime,omitempty"`
+	CurrentRoom   string  `json:"currentRoom,omitempty"`
+	SelectedActor string  `json:"selectedActor,omitempty"`
+	EasyMode      bool    `json:"easyMode,omitempty"`
+}
+
+// convertDir validates all savegames in a save directory and, if outDir
+// is not empty, converts each of them to a JSON file in outDir and copies
+// its thumbnail. The summary index is written to index.json in outDir,
+// or to standard output if outDir is empty. The exit status is 1 if a
+// savegame is invalid.
+func convertDir(dir, outDir string, f *savegame.Format) {
+	if dir == "" {
+		var err error
+		dir, err = savegame.FindDir()
+		check(err)
+	}
+	slots, err := savegame.ListDir(dir)
+	check(err)
+	if outDir != "" {
+		check(os.MkdirAll(outDir, 0o755))
+	}
+
+	idx := index{Dir: dir, Slots: make([]indexEntry, len(slots))}
+	invalid := 0
+	for i, slot := range slots {
+		e := indexEntry{
+			Name:      slot.Name,
+			Path:      slot.Path,
+			Thumbnail: slot.Thumbnail,
+		}
+		err := convertSlot(&e, slot, outDir, f)
+		if err != nil {
+			e.Error = err.Error()
+			invalid++
+		}
+		e.Valid = err == nil
+		idx.Slots[i] = e
+	}
+
+	indexData, err := json.MarshalIndent(idx, "", "  ")
+	check(err)
+	if outDir != "" {
+		check(os.WriteFile(filepath.Join(outDir, "index.json"), append(indexData, '\n'), 0o644))
+	} else {
+		fmt.Println(string(indexData))
+	}
+	if invalid > 0 {
+		fail(fmt.Sprintf("%d of %d savegames are invalid", invalid, len(slots)))
+	}
+}
+
+// convertSlot decodes a savegame, fills the index entry with its metadata
+// and converts it, if outDir is not empty.
+func convertSlot(e *indexEntry, slot savegame.Slot, outDir string, f *savegame.Format) error {
+	if slot.Err != nil {
+		return slot.Err
+	}
+	dict, format, err := decodeFile(slot.Path, f)
+	if err != nil {
+		return err
+	}
+	h, err := savegame.HeaderFromDict(dict, format)
+	if err != nil {
+		return err
+	}
+	e.Format = h.Format
+	e.SaveTime = h.SaveTime.UTC().Format(time.RFC3339)
+	e.GameTime = h.GameTime.Seconds()
+	e.CurrentRoom = h.CurrentRoom
+	e.SelectedActor = h.SelectedActor
+	e.EasyMode = h.EasyMode
+
+	if outDir == "" {
+		return nil
+	}
+	jsonData, err := ggdict.MarshalJSON(dict, jsonOptions)
+	if err != nil {
+		return err
+	}
+	e.JSON = slot.Name + ".json"
+	err = os.WriteFile(filepath.Join(outDir, e.JSON), append(jsonData, '\n'), 0o644)
+	if err != nil {
+		return err
+	}
+	if slot.Thumbnail != "" {
+		thumbnail, err := os.ReadFile(slot.Thumbnail)
+		if err != nil {
+			return err
+		}
+		e.Thumbnail = slot.Name + filepath.Ext(slot.Thumbnail)
+		err = os.WriteFile(filepath.Join(outDir, e.Thumbnail), thumbnail, 0o644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fzipp/gg/ggdict"
+	"github.com/fzipp/gg/savegame"
+)
+
+func getValue(path string, f *savegame.Format, expr string) {
+	dict, _ := load(path, f)
+	p, err := ggdict.ParsePath(expr)
+	check(err)
+	v, err := ggdict.Get(dict, p)
+	check(err)
+	text, err := ggdict.MarshalTextValue(v)
+	check(err)
+	fmt.Println(string(text))
+}
+
+func setValues(path string, f *savegame.Format, assignments []string) {
+	dict, format := load(path, f)
+	for _, assignment := range assignments {
+		p, v, err := ggdict.ParseAssignment(assignment)
+		check(err)
+		if old, err := ggdict.Get(dict, p); err == nil {
+			v = keepNumberType(old, v)
+		}
+		check(ggdict.Set(dict, p, v))
+	}
+	var buf bytes.Buffer
+	check(format.Write(&buf, dict))
+	check(writeWithBackup(path, buf.Bytes()))
+}
+
+// keepNumberType converts an integer to a float if it replaces a float,
+// so that e.g. 'gameTime=100' does not change the type of the value.
+func keepNumberType(old, v any) any {
+	if _, ok := old.(float64); ok {
+		if i, ok := v.(int); ok {
+			return float64(i)
+		}
+	}
+	return v
+}
+
+// writeWithBackup replaces the file at path with data. The original file
+// is renamed to a backup file with the extension .bak, replacing an
+// existing backup. The new data is written to a temporary file first, so
+// that the original file is not lost if writing fails.
+func writeWithBackup(path string, data []byte) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("could not create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("could not write temporary file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), fi.Mode().Perm()); err != nil {
+		return err
+	}
+	if err := os.Rename(path, path+".bak"); err != nil {
+		return fmt.Errorf("could not create backup: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// load decodes a savegame file with the format selected by -game. With
+// "auto" (f is nil) it uses the format whose checksum matches.
+func load(path string, f *savegame.Format) (map[string]any, savegame.Format) {
+	dict, format, err := decodeFile(path, f)
+	check(err)
+	return dict, format
+}
+
+// decodeFile is like load, but returns errors instead of exiting.
+func decodeFile(path string, f *savegame.Format) (map[string]any, savegame.Format, error) {
+	if f != nil {
+		dict, err := f.Load(path)
+		return dict, *f, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, savegame.Format{}, err
+	}
+	return savegame.Decode(data)
+}
+
+// outputFormat returns the format selected by -game for -from-json, where
+// there is no savegame to detect the format from. "auto" writes Thimbleweed
+// Park savegames.
+func outputFormat(f *savegame.Format) savegame.Format {
+	if f == nil {
+		return savegame.FormatThimbleweed
+	}
+	return *f
+}
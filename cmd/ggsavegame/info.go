@@ -0,0 +1,72 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fzipp/gg/savegame"
+)
+
+// info prints a summary table of the metadata of a savegame file or of
+// all savegames in a save directory. The format is detected if f is nil.
+func info(path string, f *savegame.Format) {
+	fi, err := os.Stat(path)
+	check(err)
+	readDir, readSlot := savegame.ReadDir, savegame.ReadSlot
+	if f != nil {
+		readDir, readSlot = f.ReadDir, f.ReadSlot
+	}
+	var slots []savegame.Slot
+	if fi.IsDir() {
+		slots, err = readDir(path)
+		check(err)
+	} else {
+		slots = []savegame.Slot{readSlot(path)}
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "SLOT\tSAVED\tPLAYED\tROOM\tACTOR\tMODE\tTHUMBNAIL")
+	for _, slot := range slots {
+		if slot.Err != nil {
+			_, _ = fmt.Fprintf(w, "%s\terror: %v\n", slot.Name, slot.Err)
+			continue
+		}
+		h := slot.Header
+		mode := "hard"
+		if h.EasyMode {
+			mode = "casual"
+		}
+		thumbnail := "-"
+		if slot.Thumbnail != "" {
+			thumbnail = filepath.Base(slot.Thumbnail)
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			slot.Name,
+			h.SaveTime.Format("2006-01-02 15:04"),
+			formatDuration(h.GameTime),
+			orDash(h.CurrentRoom),
+			orDash(h.SelectedActor),
+			mode,
+			thumbnail)
+	}
+	check(w.Flush())
+}
+
+// formatDuration formats a duration as hours, minutes and seconds, h:mm:ss.
+func formatDuration(d time.Duration) string {
+	s := int(d.Round(time.Second) / time.Second)
+	return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -2,93 +2,308 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// A tool to decrypt and encrypt Thimbleweed Park savegame files.
+// A tool to decrypt, encrypt and edit Thimbleweed Park savegame files.
 //
 // Usage:
 //
-//	ggsavegame -to-json|-from-json savegame_file
+//	ggsavegame [-game name] -to-json|-from-json savegame_file
+//	ggsavegame [-game name] -get path_expr savegame_file
+//	ggsavegame [-game name] -set path_expr=value [-set ...] savegame_file
+//	ggsavegame [-game name] -diff savegame_file_a savegame_file_b
+//	ggsavegame [-game name] -check|-repair savegame_file
+//	ggsavegame [-game name] -info savegame_file|save_directory
+//	ggsavegame [-game name] -dir [-out output_directory] [save_directory]
 //
 // Flags:
 //
+//	-game       The savegame format. Supported values: auto, thimbleweed.
+//	            The default is auto, which detects the format when reading
+//	            and uses thimbleweed when writing. Return to Monkey Island
+//	            and Delores savegames are not supported yet.
 //	-to-json    Converts the given savegame file to JSON format on
 //	            standard output.
 //	-from-json  Converts the given JSON file to savegame format on
 //	            standard output. You might want to redirect it to a file,
 //	            since it is a binary format.
+//	-get        Prints the value at the given path expression, e.g.
+//	            'globals.someVar', in the typed text format of ggdict.
+//	-set        Sets the value at the given path expression. The value is
+//	            given in the typed text format of ggdict, e.g.
+//	            'globals.someVar=3' or 'currentRoom="Diner"'. An integer
+//	            replacing a float is stored as float. The flag can be
+//	            repeated. The savegame file is modified in place with a
+//	            valid checksum; the original file is kept as a backup with
+//	            the extension .bak.
+//	-diff       Prints the differences between two savegame files, e.g.
+//	            changed globals, actor and object properties and inventory
+//	            items, one per line with a prefix of "+" for added, "-" for
+//	            removed and "~" for modified values.
+//	-check      Prints a diagnosis of the given savegame file: the stored
+//	            and the computed checksum, whether the decrypted data starts
+//	            with a GGDict signature (if not, the savegame was probably
+//	            encrypted with the key of a different game), the footer
+//	            bytes and whether the dictionary can be decoded. The exit
+//	            status is 1 if the savegame cannot be read.
+//	-repair     Recomputes the checksum of the given savegame file, e.g.
+//	            after it was edited by hand. The file is modified in place;
+//	            the original file is kept as a backup with the extension
+//	            .bak.
+//	-info       Prints a summary table of the metadata (save time, played
+//	            time, current room, selected actor and thumbnail image) of
+//	            the given savegame file or of all savegames in the given
+//	            save directory.
+//	-dir        Validates all savegame files (Savegame*.save) in the given
+//	            save directory and prints a summary index in JSON format
+//	            with the metadata and thumbnail image of each savegame.
+//	            Without a directory, the first of the standard Linux save
+//	            directories that contains savegames is used:
+//	              $XDG_DATA_HOME/Terrible Toybox/Thimbleweed Park
+//	              ~/.local/share/Terrible Toybox/Thimbleweed Park
+//	              ~/.steam/steam/userdata/*/569860/remote
+//	              ~/.local/share/Steam/userdata/*/569860/remote
+//	            The exit status is 1 if a savegame is invalid.
+//	-out        With -dir: Converts each savegame to a JSON file in the
+//	            given output directory, copies its thumbnail image, and
+//	            writes the summary index to index.json in it.
 //
 // Examples:
 //
 //	ggsavegame -to-json Savegame1.save > Savegame1.json
 //	ggsavegame -from-json Savegame1.json > Savegame1.save
+//	ggsavegame -get 'actors.ray._room' Savegame1.save
+//	ggsavegame -set 'globals.someVar=3' -set 'easy_mode=1' Savegame1.save
+//	ggsavegame -diff Savegame1.save Savegame2.save
+//	ggsavegame -check Savegame1.save
+//	ggsavegame -info ~/.local/share/Terrible\ Toybox/Thimbleweed\ Park
+//	ggsavegame -dir -out savegames_json
 package main
 
 import (
-	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/fzipp/gg/ggdict"
 	"github.com/fzipp/gg/savegame"
 )
 
 func usage() {
-	fail(`A tool to decrypt and encrypt Thimbleweed Park savegame files.
+	fail(`A tool to decrypt, encrypt and edit Thimbleweed Park savegame files.
 
 Usage:
-    ggsavegame -to-json|-from-json savegame_file
+    ggsavegame [-game name] -to-json|-from-json savegame_file
+    ggsavegame [-game name] -get path_expr savegame_file
+    ggsavegame [-game name] -set path_expr=value [-set ...] savegame_file
+    ggsavegame [-game name] -diff savegame_file_a savegame_file_b
+    ggsavegame [-game name] -check|-repair savegame_file
+    ggsavegame [-game name] -info savegame_file|save_directory
+    ggsavegame [-game name] -dir [-out output_directory] [save_directory]
 
 Flags:
+    -game       The savegame format. Supported values: auto, thimbleweed.
+                The default is auto, which detects the format when reading
+                and uses thimbleweed when writing. Return to Monkey Island
+                and Delores savegames are not supported yet.
     -to-json    Converts the given savegame file to JSON format on
                 standard output.
     -from-json  Converts the given JSON file to savegame format on
                 standard output. You might want to redirect it to a file,
                 since it is a binary format.
+    -get        Prints the value at the given path expression, e.g.
+                'globals.someVar', in the typed text format of ggdict.
+    -set        Sets the value at the given path expression. The value is
+                given in the typed text format of ggdict, e.g.
+                'globals.someVar=3' or 'currentRoom="Diner"'. An integer
+                replacing a float is stored as float. The flag can be
+                repeated. The savegame file is modified in place with a
+                valid checksum; the original file is kept as a backup with
+                the extension .bak.
+    -diff       Prints the differences between two savegame files, e.g.
+                changed globals, actor and object properties and inventory
+                items, one per line with a prefix of "+" for added, "-" for
+                removed and "~" for modified values.
+    -check      Prints a diagnosis of the given savegame file: the stored
+                and the computed checksum, whether the decrypted data starts
+                with a GGDict signature (if not, the savegame was probably
+                encrypted with the key of a different game), the footer
+                bytes and whether the dictionary can be decoded. The exit
+                status is 1 if the savegame cannot be read.
+    -repair     Recomputes the checksum of the given savegame file, e.g.
+                after it was edited by hand. The file is modified in place;
+                the original file is kept as a backup with the extension
+                .bak.
+    -info       Prints a summary table of the metadata (save time, played
+                time, current room, selected actor and thumbnail image) of
+                the given savegame file or of all savegames in the given
+                save directory.
+    -dir        Validates all savegame files (Savegame*.save) in the given
+                save directory and prints a summary index in JSON format
+                with the metadata and thumbnail image of each savegame.
+                Without a directory, the first of the standard Linux save
+                directories that contains savegames is used:
+                  $XDG_DATA_HOME/Terrible Toybox/Thimbleweed Park
+                  ~/.local/share/Terrible Toybox/Thimbleweed Park
+                  ~/.steam/steam/userdata/*/569860/remote
+                  ~/.local/share/Steam/userdata/*/569860/remote
+                The exit status is 1 if a savegame is invalid.
+    -out        With -dir: Converts each savegame to a JSON file in the
+                given output directory, copies its thumbnail image, and
+                writes the summary index to index.json in it.
 
 Examples:
     ggsavegame -to-json Savegame1.save > Savegame1.json
-    ggsavegame -from-json Savegame1.json > Savegame1.save`)
+    ggsavegame -from-json Savegame1.json > Savegame1.save
+    ggsavegame -get 'actors.ray._room' Savegame1.save
+    ggsavegame -set 'globals.someVar=3' -set 'easy_mode=1' Savegame1.save
+    ggsavegame -diff Savegame1.save Savegame2.save
+    ggsavegame -check Savegame1.save
+    ggsavegame -info ~/.local/share/Terrible\ Toybox/Thimbleweed\ Park
+    ggsavegame -dir -out savegames_json`)
 }
 
+var seeHelp = "See -help for more information."
+
 func main() {
 	savegameFilePath := flag.String("to-json", "", "")
 	jsonFilePath := flag.String("from-json", "", "")
+	getExpr := flag.String("get", "", "")
+	var assignments stringList
+	flag.Var(&assignments, "set", "")
+	diffFlag := flag.Bool("diff", false, "")
+	checkPath := flag.String("check", "", "")
+	repairPath := flag.String("repair", "", "")
+	infoPath := flag.String("info", "", "")
+	dirFlag := flag.Bool("dir", false, "")
+	outDir := flag.String("out", "", "")
+	gameName := flag.String("game", "auto", "")
 
 	flag.Usage = usage
 	flag.Parse()
 
-	if *savegameFilePath == "" && *jsonFilePath == "" {
+	operations := 0
+	for _, op := range []string{*savegameFilePath, *jsonFilePath, *getExpr, *checkPath, *repairPath, *infoPath} {
+		if op != "" {
+			operations++
+		}
+	}
+	if len(assignments) > 0 {
+		operations++
+	}
+	if *diffFlag {
+		operations++
+	}
+	if *dirFlag {
+		operations++
+	}
+	if operations == 0 {
 		usage()
 	}
-	if *savegameFilePath != "" && *jsonFilePath != "" {
-		fail("-from-json and -to-json flags cannot be used together. See -help for more information.")
+	if operations > 1 {
+		fail("Please use only one operation flag, not multiple at the same time. " + seeHelp)
+	}
+	if *outDir != "" && !*dirFlag {
+		fail("The -out flag can only be used with -dir. " + seeHelp)
+	}
+
+	var format *savegame.Format
+	if *gameName != "auto" {
+		f, ok := savegame.FormatByName(strings.ToLower(*gameName))
+		if !ok {
+			fail(`Unknown game: "` + *gameName + `". ` + seeHelp)
+		}
+		format = &f
+	}
+
+	if *infoPath != "" {
+		info(*infoPath, format)
+		return
+	}
+
+	if *checkPath != "" {
+		checkFile(*checkPath, format)
+		return
+	}
+
+	if *repairPath != "" {
+		repairFile(*repairPath, format)
+		return
 	}
 
 	if *savegameFilePath != "" {
-		toJSON(*savegameFilePath)
+		toJSON(*savegameFilePath, format)
 		return
 	}
 
 	if *jsonFilePath != "" {
-		fromJSON(*jsonFilePath)
+		fromJSON(*jsonFilePath, format)
+		return
+	}
+
+	if *dirFlag {
+		if flag.NArg() > 1 {
+			fail("Please specify at most one save directory. " + seeHelp)
+		}
+		convertDir(flag.Arg(0), *outDir, format)
+		return
+	}
+
+	if *diffFlag {
+		if flag.NArg() != 2 {
+			fail("Please specify two savegame files to compare. " + seeHelp)
+		}
+		diff(flag.Arg(0), flag.Arg(1), format)
+		return
+	}
+
+	if flag.NArg() != 1 {
+		fail("Please specify exactly one savegame file. " + seeHelp)
+	}
+	path := flag.Arg(0)
+
+	if *getExpr != "" {
+		getValue(path, format, *getExpr)
+		return
+	}
+
+	if len(assignments) > 0 {
+		setValues(path, format, assignments)
 		return
 	}
 }
 
-func toJSON(path string) {
-	dict, err := savegame.Load(path)
-	check(err)
-	jsonData, err := json.MarshalIndent(dict, "", "  ")
+// stringList is a flag that can be repeated.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// jsonOptions keep the distinction between integers and floats,
+// so that a savegame can be converted to JSON and back without changes.
+var jsonOptions = ggdict.JSONOptions{
+	Indent:          "  ",
+	PreserveNumbers: true,
+}
+
+func toJSON(path string, f *savegame.Format) {
+	dict, _ := load(path, f)
+	jsonData, err := ggdict.MarshalJSON(dict, jsonOptions)
 	check(err)
 	fmt.Println(string(jsonData))
 }
 
-func fromJSON(path string) {
+func fromJSON(path string, f *savegame.Format) {
 	jsonData, err := os.ReadFile(path)
 	check(err)
-	dict := make(map[string]any)
-	err = json.Unmarshal(jsonData, &dict)
+	dict, err := ggdict.UnmarshalJSON(jsonData, jsonOptions)
 	check(err)
-	err = savegame.Write(os.Stdout, dict)
+	err = outputFormat(f).Write(os.Stdout, dict)
 	check(err)
 }
 
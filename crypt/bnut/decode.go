@@ -7,9 +7,9 @@ package bnut
 import (
 	"io"
 
-	"github.com/fzipp/gg/crypt/internal/transform"
+	"github.com/fzipp/gg/crypt/transform"
 )
 
 func DecodingReader(r io.Reader, expectedSize int64) io.Reader {
-	return transform.NewReader(r, newTransformer(expectedSize))
+	return transform.NewReader(r, NewTransformer(expectedSize))
 }
@@ -7,24 +7,39 @@ package bnut
 import (
 	"io"
 
-	"github.com/fzipp/gg/crypt/internal/transform"
+	"github.com/fzipp/gg/crypt/transform"
 )
 
 type transformer struct {
+	start  int
 	cursor int
 }
 
-func newTransformer(expectedSize int64) transform.Transformer {
-	return &transformer{cursor: int(expectedSize & 0xff)}
+// NewTransformer returns the transformer that encodes and decodes .bnut
+// data of the given size. Encoding and decoding are the same operation.
+func NewTransformer(expectedSize int64) transform.SeekableTransformer {
+	start := int(expectedSize & 0xff)
+	return &transformer{start: start, cursor: start}
 }
 
 func (t *transformer) Transform(dst, src []byte) {
+	t.cursor = xorKey(dst, src, t.cursor)
+}
+
+func (t *transformer) TransformAt(dst, src []byte, off int64) {
+	xorKey(dst, src, int((int64(t.start)+off)%int64(len(cryptKey))))
+}
+
+// xorKey combines src with the key starting at the cursor
+// and returns the cursor after the last byte.
+func xorKey(dst, src []byte, cursor int) int {
 	for i := 0; i < len(src); i++ {
-		dst[i] = src[i] ^ cryptKey[t.cursor]
-		t.cursor = (t.cursor + 1) % len(cryptKey)
+		dst[i] = src[i] ^ cryptKey[cursor]
+		cursor = (cursor + 1) % len(cryptKey)
 	}
+	return cursor
 }
 
 func EncodingWriter(w io.Writer, expectedSize int64) io.Writer {
-	return transform.NewWriter(w, newTransformer(expectedSize))
+	return transform.NewWriter(w, NewTransformer(expectedSize))
 }
@@ -10,6 +10,8 @@ import (
 	"testing"
 
 	"github.com/fzipp/gg/crypt/bnut"
+	"github.com/fzipp/gg/crypt/transform"
+	"github.com/fzipp/gg/crypt/transform/transformtest"
 )
 
 var testBnutScript = `__<-"This is a test input."
@@ -43,3 +45,13 @@ func TestWriterReaderRoundTrip(t *testing.T) {
 		t.Errorf("decoded data is not equal to original data! Original: %q vs. decoded: %q", string(original), string(decoded))
 	}
 }
+
+func TestConformance(t *testing.T) {
+	codec := transformtest.Codec{
+		NewEncoder: func(size int64) transform.Transformer { return bnut.NewTransformer(size) },
+		NewDecoder: func(size int64) transform.Transformer { return bnut.NewTransformer(size) },
+	}
+	if err := transformtest.TestCodec(codec); err != nil {
+		t.Errorf("transformers do not conform:\n%s", err)
+	}
+}
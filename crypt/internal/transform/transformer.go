@@ -1,9 +0,0 @@
-// Copyright 2020 Frederik Zipp. All rights reserved.
-// Use of this source code is governed by a BSD-style
-// license that can be found in the LICENSE file.
-
-package transform
-
-type Transformer interface {
-	Transform(dst, src []byte)
-}
@@ -0,0 +1,45 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package transform
+
+// Chain returns a transformer that applies the transformers in order, each
+// to the output of the previous one. If all transformers are seekable, the
+// returned transformer is a SeekableTransformer.
+func Chain(ts ...Transformer) Transformer {
+	for _, t := range ts {
+		if _, ok := t.(SeekableTransformer); !ok {
+			return chain(ts)
+		}
+	}
+	return seekableChain{chain(ts)}
+}
+
+type chain []Transformer
+
+func (c chain) Transform(dst, src []byte) {
+	if len(c) == 0 {
+		copy(dst, src)
+		return
+	}
+	c[0].Transform(dst, src)
+	for _, t := range c[1:] {
+		t.Transform(dst[:len(src)], dst[:len(src)])
+	}
+}
+
+type seekableChain struct {
+	chain
+}
+
+func (c seekableChain) TransformAt(dst, src []byte, off int64) {
+	if len(c.chain) == 0 {
+		copy(dst, src)
+		return
+	}
+	c.chain[0].(SeekableTransformer).TransformAt(dst, src, off)
+	for _, t := range c.chain[1:] {
+		t.(SeekableTransformer).TransformAt(dst[:len(src)], dst[:len(src)], off)
+	}
+}
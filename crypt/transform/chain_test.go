@@ -0,0 +1,78 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package transform_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/fzipp/gg/crypt/transform"
+)
+
+func TestChain(t *testing.T) {
+	tests := []struct {
+		chain        transform.Transformer
+		input        string
+		want         string
+		wantSeekable bool
+	}{
+		{transform.Chain(), "abc", "abc", true},
+		{transform.Chain(upperCaseTransformer{}), "abc", "ABC", false},
+		{transform.Chain(&offsetXORTransformer{}, &offsetXORTransformer{}), "abc", "abc", true},
+		{transform.Chain(&offsetXORTransformer{}, upperCaseTransformer{}), "abc", "ACA", false},
+	}
+	for _, tt := range tests {
+		dst := make([]byte, len(tt.input))
+		tt.chain.Transform(dst, []byte(tt.input))
+		if string(dst) != tt.want {
+			t.Errorf("transforming %q with chain resulted in %q, want: %q", tt.input, dst, tt.want)
+		}
+		if _, seekable := tt.chain.(transform.SeekableTransformer); seekable != tt.wantSeekable {
+			t.Errorf("chain is seekable: %v, want: %v", seekable, tt.wantSeekable)
+		}
+	}
+}
+
+func TestReaderAt(t *testing.T) {
+	input := "\x00\x01\x02\x03\x04\x05\x06\x07"
+	r := transform.NewReaderAt(strings.NewReader(input), &offsetXORTransformer{})
+	tests := []struct {
+		off  int64
+		n    int
+		want string
+		err  error
+	}{
+		{0, 8, "\x00\x00\x00\x00\x00\x00\x00\x00", nil},
+		{3, 2, "\x00\x00", nil},
+		{6, 4, "\x00\x00", io.EOF},
+	}
+	for _, tt := range tests {
+		p := make([]byte, tt.n)
+		n, err := r.ReadAt(p, tt.off)
+		if err != tt.err {
+			t.Errorf("reading at offset %d returned error: %v, want: %v", tt.off, err, tt.err)
+		}
+		if got := string(p[:n]); got != tt.want {
+			t.Errorf("reading at offset %d resulted in %q, want: %q", tt.off, got, tt.want)
+		}
+	}
+}
+
+// offsetXORTransformer combines each byte with the low byte of its offset.
+type offsetXORTransformer struct {
+	offset int64
+}
+
+func (t *offsetXORTransformer) Transform(dst, src []byte) {
+	t.TransformAt(dst, src, t.offset)
+	t.offset += int64(len(src))
+}
+
+func (t *offsetXORTransformer) TransformAt(dst, src []byte, off int64) {
+	for i, b := range src {
+		dst[i] = b ^ byte(off+int64(i))
+	}
+}
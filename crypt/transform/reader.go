@@ -17,6 +17,7 @@ func (r *reader) Read(p []byte) (n int, err error) {
 	return n, err
 }
 
+// NewReader returns a reader that transforms the bytes read from r.
 func NewReader(r io.Reader, t Transformer) io.Reader {
 	return &reader{
 		reader:      r,
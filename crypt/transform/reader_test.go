@@ -10,7 +10,7 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/fzipp/gg/crypt/internal/transform"
+	"github.com/fzipp/gg/crypt/transform"
 )
 
 func TestReader(t *testing.T) {
@@ -0,0 +1,28 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package transform
+
+import "io"
+
+type readerAt struct {
+	readerAt    io.ReaderAt
+	transformer SeekableTransformer
+}
+
+func (r *readerAt) ReadAt(p []byte, off int64) (n int, err error) {
+	n, err = r.readerAt.ReadAt(p, off)
+	r.transformer.TransformAt(p[:n], p[:n], off)
+	return n, err
+}
+
+// NewReaderAt returns an io.ReaderAt that transforms the bytes read from r
+// at any offset. Since the transformer is not modified, the returned
+// ReaderAt can be used concurrently if r can.
+func NewReaderAt(r io.ReaderAt, t SeekableTransformer) io.ReaderAt {
+	return &readerAt{
+		readerAt:    r,
+		transformer: t,
+	}
+}
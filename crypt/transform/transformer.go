@@ -0,0 +1,31 @@
+// Copyright 2020 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package transform provides the building blocks of the ciphers used by the
+// games: transformers that encode or decode bytes, and readers and writers
+// that apply them to streams.
+//
+// The conformance of a new cipher's transformers can be tested with the
+// transformtest package.
+package transform
+
+// Transformer transforms bytes, e.g. encodes or decodes them. A transformer
+// is stateful: each call to Transform continues where the previous call
+// ended, so data can be transformed in chunks of any size.
+//
+// Transform writes len(src) bytes to dst, which must be at least as long as
+// src. dst and src may be the same slice.
+type Transformer interface {
+	Transform(dst, src []byte)
+}
+
+// SeekableTransformer is a Transformer that can transform bytes at any
+// offset of the data without transforming the preceding bytes first.
+type SeekableTransformer interface {
+	Transformer
+	// TransformAt transforms src, which is located at offset off of the
+	// data, like Transform would after transforming off bytes. It does not
+	// change the state of the transformer.
+	TransformAt(dst, src []byte, off int64)
+}
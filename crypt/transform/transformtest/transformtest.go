@@ -0,0 +1,130 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package transformtest implements support for testing implementations
+// of ciphers based on the transform package.
+package transformtest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/fzipp/gg/crypt/transform"
+)
+
+// Codec creates the transformers of a cipher for data of a given size.
+type Codec struct {
+	NewEncoder func(size int64) transform.Transformer
+	NewDecoder func(size int64) transform.Transformer
+}
+
+// sizes are the data sizes that are tested. They cover the boundaries of
+// typical word and table sizes.
+var sizes = []int{0, 1, 2, 3, 7, 8, 9, 15, 16, 17, 255, 256, 257, 1000, 4099}
+
+// chunkSizes are the sizes of the chunks in which data is transformed.
+var chunkSizes = []int{1, 3, 8, 13, 64}
+
+// TestCodec tests a cipher's transformers for data of various sizes.
+// It checks that
+//   - decoding encoded data results in the original data,
+//   - transforming data in chunks of any size has the same result as
+//     transforming it at once,
+//   - transforming data in place has the same result,
+//   - Transform does not write to dst beyond the length of src, and
+//   - transformers that implement transform.SeekableTransformer transform
+//     data at any offset like Transform.
+//
+// It returns an error describing all problems found.
+func TestCodec(c Codec) error {
+	var problems []string
+	rnd := rand.New(rand.NewSource(1))
+	for _, size := range sizes {
+		data := make([]byte, size)
+		rnd.Read(data)
+		encoded := transformAll(c.NewEncoder(int64(size)), data)
+		decoded := transformAll(c.NewDecoder(int64(size)), encoded)
+		if !bytes.Equal(decoded, data) {
+			problems = append(problems, fmt.Sprintf("size %d: decoding encoded data does not result in the original data", size))
+		}
+		for _, tt := range []struct {
+			name     string
+			newT     func(size int64) transform.Transformer
+			src, dst []byte
+		}{
+			{"encoder", c.NewEncoder, data, encoded},
+			{"decoder", c.NewDecoder, encoded, data},
+		} {
+			for _, err := range check(tt.newT, tt.src, tt.dst) {
+				problems = append(problems, fmt.Sprintf("size %d: %s: %s", size, tt.name, err))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+func check(newT func(size int64) transform.Transformer, src, want []byte) []error {
+	var errs []error
+	size := int64(len(src))
+
+	for _, chunkSize := range chunkSizes {
+		t := newT(size)
+		got := make([]byte, len(src))
+		for i := 0; i < len(src); i += chunkSize {
+			end := i + chunkSize
+			if end > len(src) {
+				end = len(src)
+			}
+			t.Transform(got[i:end], src[i:end])
+		}
+		if !bytes.Equal(got, want) {
+			errs = append(errs, fmt.Errorf("transforming in chunks of %d bytes differs from transforming at once", chunkSize))
+		}
+	}
+
+	inPlace := append([]byte(nil), src...)
+	newT(size).Transform(inPlace, inPlace)
+	if !bytes.Equal(inPlace, want) {
+		errs = append(errs, errors.New("transforming in place differs from transforming into a separate slice"))
+	}
+
+	const guard = 0xA5
+	dst := bytes.Repeat([]byte{guard}, len(src)+8)
+	newT(size).Transform(dst, src)
+	if bytes.Count(dst[len(src):], []byte{guard}) != 8 {
+		errs = append(errs, errors.New("Transform writes beyond the length of src"))
+	}
+
+	if t, ok := newT(size).(transform.SeekableTransformer); ok {
+		for _, off := range []int{0, 1, len(src) / 3, len(src) / 2, len(src) - 1} {
+			if off < 0 || off > len(src) {
+				continue
+			}
+			got := make([]byte, len(src)-off)
+			t.TransformAt(got, src[off:], int64(off))
+			if !bytes.Equal(got, want[off:]) {
+				errs = append(errs, fmt.Errorf("TransformAt offset %d differs from Transform", off))
+			}
+		}
+		// TransformAt must not change the state used by Transform.
+		got := make([]byte, len(src))
+		t.Transform(got, src)
+		if !bytes.Equal(got, want) {
+			errs = append(errs, errors.New("TransformAt changes the state of the transformer"))
+		}
+	}
+	return errs
+}
+
+func transformAll(t transform.Transformer, src []byte) []byte {
+	dst := make([]byte, len(src))
+	t.Transform(dst, src)
+	return dst
+}
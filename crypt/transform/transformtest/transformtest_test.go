@@ -0,0 +1,59 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package transformtest_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fzipp/gg/crypt/transform"
+	"github.com/fzipp/gg/crypt/transform/transformtest"
+)
+
+func TestTestCodec(t *testing.T) {
+	good := transformtest.Codec{
+		NewEncoder: func(size int64) transform.Transformer { return &runningXOR{} },
+		NewDecoder: func(size int64) transform.Transformer { return &runningXOR{} },
+	}
+	if err := transformtest.TestCodec(good); err != nil {
+		t.Errorf("testing a conforming codec returned an error: %s", err)
+	}
+
+	bad := transformtest.Codec{
+		NewEncoder: func(size int64) transform.Transformer { return &chunkXOR{} },
+		NewDecoder: func(size int64) transform.Transformer { return &chunkXOR{} },
+	}
+	err := transformtest.TestCodec(bad)
+	if err == nil {
+		t.Errorf("expected error for testing a non-conforming codec, but no error returned")
+		return
+	}
+	want := "size 2: encoder: transforming in chunks of 1 bytes differs from transforming at once"
+	if !strings.Contains(err.Error(), want) {
+		t.Errorf("error for testing a non-conforming codec was: %q, want it to contain: %q", err, want)
+	}
+}
+
+// runningXOR combines each byte with a counter that continues
+// across calls to Transform.
+type runningXOR struct {
+	counter byte
+}
+
+func (t *runningXOR) Transform(dst, src []byte) {
+	for i, b := range src {
+		dst[i] = b ^ t.counter
+		t.counter++
+	}
+}
+
+// chunkXOR does not keep its state across calls to Transform.
+type chunkXOR struct{}
+
+func (t *chunkXOR) Transform(dst, src []byte) {
+	for i, b := range src {
+		dst[i] = b ^ byte(i)
+	}
+}
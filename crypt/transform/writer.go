@@ -0,0 +1,47 @@
+// Copyright 2020 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package transform
+
+import "io"
+
+// maxBufferSize is the maximum size of the buffer of a writer. Larger
+// writes are transformed and written in chunks of this size.
+const maxBufferSize = 32 * 1024
+
+type writer struct {
+	writer      io.Writer
+	transformer Transformer
+	buf         []byte
+}
+
+func (w *writer) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > maxBufferSize {
+			chunk = chunk[:maxBufferSize]
+		}
+		if cap(w.buf) < len(chunk) {
+			w.buf = make([]byte, len(chunk))
+		}
+		dst := w.buf[:len(chunk)]
+		w.transformer.Transform(dst, chunk)
+		m, err := w.writer.Write(dst)
+		n += m
+		if err != nil {
+			return n, err
+		}
+		p = p[len(chunk):]
+	}
+	return n, nil
+}
+
+// NewWriter returns a writer that transforms the bytes before writing
+// them to w.
+func NewWriter(w io.Writer, t Transformer) io.Writer {
+	return &writer{
+		writer:      w,
+		transformer: t,
+	}
+}
@@ -9,7 +9,7 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/fzipp/gg/crypt/internal/transform"
+	"github.com/fzipp/gg/crypt/transform"
 )
 
 func TestWriter(t *testing.T) {
@@ -0,0 +1,163 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xor
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/fzipp/gg/crypt/xor/internal/keyscan"
+	"github.com/fzipp/gg/crypt/xor/rtmi"
+	"github.com/fzipp/gg/crypt/xor/twp"
+)
+
+// ExtractedKey is a key found in a game's executable file by ExtractKeys.
+type ExtractedKey struct {
+	// Name is the name of the known key the found key belongs to.
+	Name string
+	// Family is the type of the key: KeyTypeTWP or KeyTypeRtMI.
+	Family string
+	// Variant reports whether the found magic bytes differ in a few bytes
+	// from the magic bytes of the known key, as is the case for keys of
+	// other builds of a game.
+	Variant bool
+	// Offset is the offset of the magic bytes within the executable file.
+	// For RtMI keys it is the offset of the short magic bytes.
+	Offset int64
+	// Key is the found key. Its multiplier or modifier is not extracted,
+	// but taken from the known key.
+	Key Key
+}
+
+// maxMismatchRatio determines the number of bytes in which the magic bytes
+// of a key variant may differ from the magic bytes of a known key.
+const maxMismatchRatio = 8
+
+// ExtractKeys scans a game's executable file once for the magic bytes of
+// all known and registered keys, including keys that need loading. Magic
+// bytes that differ from the magic bytes of a known Thimbleweed Park or
+// Delores key in at most one of eight bytes are reported as variants, but
+// only if they start with the same byte. The data sections of ELF, PE and
+// Mach-O executables are scanned first.
+//
+// The returned keys are ordered by offset and name.
+func ExtractKeys(execFile string) ([]ExtractedKey, error) {
+	exec, err := os.ReadFile(execFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not read executable file: %w", err)
+	}
+	return extractKeys(exec), nil
+}
+
+func extractKeys(exec []byte) []ExtractedKey {
+	var (
+		sigs      []keyscan.Signature
+		sigByKey  = map[string]int{}
+		twpKeys   = map[int][]string{}
+		rtmiKeys  []string
+		rtmiFirst = -1
+	)
+	for _, name := range KeyNames() {
+		key, _ := LookupKey(name)
+		switch k := unwrapFormat(key).(type) {
+		case *twp.Key:
+			if len(k.MagicBytes) == 0 {
+				continue
+			}
+			i, ok := sigByKey[string(k.MagicBytes)]
+			if !ok {
+				i = len(sigs)
+				sigByKey[string(k.MagicBytes)] = i
+				sigs = append(sigs, keyscan.Signature{
+					Bytes:         k.MagicBytes,
+					MaxMismatches: len(k.MagicBytes) / maxMismatchRatio,
+				})
+			}
+			twpKeys[i] = append(twpKeys[i], name)
+		case *rtmi.Key:
+			if rtmiFirst < 0 {
+				rtmiFirst = len(sigs)
+				sigs = append(sigs, keyscan.RtMIMagicBytes1, keyscan.RtMIMagicBytes2)
+			}
+			rtmiKeys = append(rtmiKeys, name)
+		}
+	}
+
+	var (
+		extracted   []ExtractedKey
+		rtmiMatches = map[int]keyscan.Match{}
+	)
+	matches := keyscan.ScanExecutable(exec, sigs)
+	// Magic bytes are only attributed to the known keys they are closest to.
+	closest := map[int]int{}
+	for _, m := range matches {
+		if c, ok := closest[m.Offset]; !ok || m.Mismatches < c {
+			closest[m.Offset] = m.Mismatches
+		}
+	}
+	for _, m := range matches {
+		if m.Mismatches > closest[m.Offset] {
+			continue
+		}
+		if rtmiFirst >= 0 && (m.Signature == rtmiFirst || m.Signature == rtmiFirst+1) {
+			rtmiMatches[m.Signature-rtmiFirst] = m
+			continue
+		}
+		magicBytes := m.Bytes(exec, sigs)
+		for _, name := range twpKeys[m.Signature] {
+			known, _ := LookupKey(name)
+			k := unwrapFormat(known).(*twp.Key)
+			extracted = append(extracted, ExtractedKey{
+				Name:    name,
+				Family:  KeyTypeTWP,
+				Variant: m.Mismatches > 0,
+				Offset:  int64(m.Offset),
+				Key:     rewrapFormat(known, &twp.Key{MagicBytes: magicBytes, Multiplier: k.Multiplier}),
+			})
+		}
+	}
+	if len(rtmiMatches) == 2 {
+		m1, m2 := rtmiMatches[0], rtmiMatches[1]
+		for _, name := range rtmiKeys {
+			known, _ := LookupKey(name)
+			k := unwrapFormat(known).(*rtmi.Key)
+			extracted = append(extracted, ExtractedKey{
+				Name:   name,
+				Family: KeyTypeRtMI,
+				Offset: int64(m1.Offset),
+				Key: rewrapFormat(known, &rtmi.Key{
+					MagicBytes1: m1.Bytes(exec, sigs),
+					MagicBytes2: m2.Bytes(exec, sigs),
+					Modifier:    k.Modifier,
+				}),
+			})
+		}
+	}
+	sort.Slice(extracted, func(i, j int) bool {
+		if extracted[i].Offset != extracted[j].Offset {
+			return extracted[i].Offset < extracted[j].Offset
+		}
+		return extracted[i].Name < extracted[j].Name
+	})
+	return extracted
+}
+
+// unwrapFormat returns the key without its GGDictionary format override.
+func unwrapFormat(key Key) Key {
+	if k, ok := key.(*keyWithFormat); ok {
+		return k.Key
+	}
+	return key
+}
+
+// rewrapFormat applies the GGDictionary format override of the original
+// key to the extracted key.
+func rewrapFormat(original, extracted Key) Key {
+	if k, ok := original.(*keyWithFormat); ok {
+		return &keyWithFormat{Key: extracted, format: k.format}
+	}
+	return extracted
+}
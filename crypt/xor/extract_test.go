@@ -0,0 +1,62 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xor_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/fzipp/gg/crypt/xor"
+	"github.com/fzipp/gg/crypt/xor/twp"
+)
+
+func TestExtractKeys(t *testing.T) {
+	delores := mustLookupKey(t, "delores").(*twp.Key).MagicBytes
+	variant := append([]byte{}, mustLookupKey(t, "thimbleweed").(*twp.Key).MagicBytes...)
+	variant[10] = 0x42
+
+	var exec []byte
+	exec = append(exec, make([]byte, 100)...)
+	exec = append(exec, delores...)
+	exec = append(exec, make([]byte, 50)...)
+	exec = append(exec, variant...)
+	execFile := filepath.Join(t.TempDir(), "game.exe")
+	if err := os.WriteFile(execFile, exec, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := xor.ExtractKeys(execFile)
+	if err != nil {
+		t.Errorf("extracting keys returned an error: %s", err)
+		return
+	}
+	want := []xor.ExtractedKey{
+		{
+			Name:   "delores",
+			Family: xor.KeyTypeTWP,
+			Offset: 100,
+			Key:    &twp.Key{MagicBytes: delores, Multiplier: 0x6D},
+		},
+		{
+			Name:    "thimbleweed",
+			Family:  xor.KeyTypeTWP,
+			Variant: true,
+			Offset:  198,
+			Key:     &twp.Key{MagicBytes: variant, Multiplier: 0xAD},
+		},
+		{
+			Name:    "thimbleweed-566d",
+			Family:  xor.KeyTypeTWP,
+			Variant: true,
+			Offset:  198,
+			Key:     &twp.Key{MagicBytes: variant, Multiplier: 0x6D},
+		},
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("extracting keys resulted in %#v, want: %#v", keys, want)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package keyscan finds XOR key material in game executables.
+package keyscan
+
+import (
+	"crypto/md5"
+	"sort"
+)
+
+// Signature describes key material to be found.
+//
+// If Bytes is set, the key material is known and a match may differ from it
+// in at most MaxMismatches bytes, which finds variants of a known key.
+// Otherwise only the MD5 checksum of the key material is known, and a match
+// must start with FirstByte and have the checksum Sum.
+type Signature struct {
+	Bytes         []byte
+	MaxMismatches int
+
+	Length    int
+	FirstByte byte
+	Sum       [md5.Size]byte
+}
+
+func (s *Signature) length() int {
+	if s.Bytes != nil {
+		return len(s.Bytes)
+	}
+	return s.Length
+}
+
+func (s *Signature) firstByte() byte {
+	if s.Bytes != nil {
+		return s.Bytes[0]
+	}
+	return s.FirstByte
+}
+
+// Match is the location of key material in the scanned data.
+type Match struct {
+	// Signature is the index of the matched signature.
+	Signature int
+	// Offset is the offset of the key material within the scanned data.
+	Offset int
+	// Mismatches is the number of bytes that differ from the
+	// signature's Bytes.
+	Mismatches int
+}
+
+// Bytes returns the matched key material.
+func (m Match) Bytes(data []byte, sigs []Signature) []byte {
+	n := sigs[m.Signature].length()
+	b := make([]byte, n)
+	copy(b, data[m.Offset:m.Offset+n])
+	return b
+}
+
+// Scan searches the regions of data for the signatures in a single pass.
+// Candidate offsets are selected by their first byte, so that only
+// signatures starting with this byte are compared at each offset.
+//
+// It returns at most one match per signature, the first exact match or,
+// if there is none, the first match with the fewest mismatches.
+// The matches are ordered by signature.
+func Scan(data []byte, regions []Region, sigs []Signature) []Match {
+	var byFirstByte [256][]int
+	for i := range sigs {
+		fb := sigs[i].firstByte()
+		byFirstByte[fb] = append(byFirstByte[fb], i)
+	}
+	best := make([]*Match, len(sigs))
+	remaining := len(sigs)
+	for _, r := range regions {
+		for offset := r.Offset; offset < r.End() && remaining > 0; offset++ {
+			candidates := byFirstByte[data[offset]]
+			for _, i := range candidates {
+				if best[i] != nil && best[i].Mismatches == 0 {
+					continue
+				}
+				sig := &sigs[i]
+				if offset+sig.length() > r.End() {
+					continue
+				}
+				mismatches, ok := compare(data[offset:offset+sig.length()], sig)
+				if !ok || (best[i] != nil && best[i].Mismatches <= mismatches) {
+					continue
+				}
+				best[i] = &Match{Signature: i, Offset: offset, Mismatches: mismatches}
+				if mismatches == 0 {
+					remaining--
+				}
+			}
+		}
+	}
+	var matches []Match
+	for _, m := range best {
+		if m != nil {
+			matches = append(matches, *m)
+		}
+	}
+	return matches
+}
+
+// ScanExecutable searches the data regions of an executable file for the
+// signatures. Signatures that are not found in the data regions, e.g.
+// because the key material is embedded in a code section, are searched
+// in the whole file.
+func ScanExecutable(exec []byte, sigs []Signature) []Match {
+	matches := Scan(exec, DataRegions(exec), sigs)
+	if len(matches) == len(sigs) {
+		return matches
+	}
+	found := make([]bool, len(sigs))
+	for _, m := range matches {
+		found[m.Signature] = true
+	}
+	var (
+		missing        []Signature
+		missingIndices []int
+	)
+	for i, sig := range sigs {
+		if !found[i] {
+			missing = append(missing, sig)
+			missingIndices = append(missingIndices, i)
+		}
+	}
+	whole := []Region{{Offset: 0, Size: len(exec)}}
+	for _, m := range Scan(exec, whole, missing) {
+		m.Signature = missingIndices[m.Signature]
+		matches = append(matches, m)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Signature < matches[j].Signature
+	})
+	return matches
+}
+
+// compare compares a candidate with a signature and returns the number
+// of mismatching bytes.
+func compare(candidate []byte, sig *Signature) (mismatches int, ok bool) {
+	if sig.Bytes == nil {
+		return 0, md5.Sum(candidate) == sig.Sum
+	}
+	for i, b := range sig.Bytes {
+		if candidate[i] != b {
+			mismatches++
+			if mismatches > sig.MaxMismatches {
+				return mismatches, false
+			}
+		}
+	}
+	return mismatches, true
+}
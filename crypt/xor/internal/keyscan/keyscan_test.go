@@ -0,0 +1,92 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package keyscan_test
+
+import (
+	"crypto/md5"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/fzipp/gg/crypt/xor/internal/keyscan"
+)
+
+func TestScan(t *testing.T) {
+	data := []byte("..abcdefgh..abXdefgh..12345678..abcdefgh..")
+	sigs := []keyscan.Signature{
+		{Bytes: []byte("abcdefgh"), MaxMismatches: 1},
+		{Bytes: []byte("abcdXfgX"), MaxMismatches: 2},
+		{Length: 8, FirstByte: '1', Sum: md5.Sum([]byte("12345678"))},
+		{Length: 8, FirstByte: '1', Sum: md5.Sum([]byte("12345679"))},
+		{Bytes: []byte("zzzzzzzz"), MaxMismatches: 1},
+	}
+	tests := []struct {
+		regions []keyscan.Region
+		want    []keyscan.Match
+	}{
+		{
+			[]keyscan.Region{{Offset: 0, Size: len(data)}},
+			[]keyscan.Match{
+				{Signature: 0, Offset: 2, Mismatches: 0},
+				{Signature: 1, Offset: 2, Mismatches: 2},
+				{Signature: 2, Offset: 22, Mismatches: 0},
+			},
+		},
+		{
+			[]keyscan.Region{{Offset: 12, Size: 18}},
+			[]keyscan.Match{
+				{Signature: 0, Offset: 12, Mismatches: 1},
+				{Signature: 2, Offset: 22, Mismatches: 0},
+			},
+		},
+	}
+	for _, tt := range tests {
+		matches := keyscan.Scan(data, tt.regions, sigs)
+		if !reflect.DeepEqual(matches, tt.want) {
+			t.Errorf("scanning regions %v resulted in %v, want: %v", tt.regions, matches, tt.want)
+		}
+	}
+}
+
+func TestScanExecutable(t *testing.T) {
+	data := []byte("not an executable, key: abcdefgh")
+	sigs := []keyscan.Signature{{Bytes: []byte("abcdefgh")}}
+	matches := keyscan.ScanExecutable(data, sigs)
+	want := []keyscan.Match{{Signature: 0, Offset: 24}}
+	if !reflect.DeepEqual(matches, want) {
+		t.Errorf("scanning executable resulted in %v, want: %v", matches, want)
+	}
+	if b := matches[0].Bytes(data, sigs); string(b) != "abcdefgh" {
+		t.Errorf("matched bytes were %q, want: %q", b, "abcdefgh")
+	}
+}
+
+func TestDataRegions(t *testing.T) {
+	data := []byte("not an executable")
+	want := []keyscan.Region{{Offset: 0, Size: len(data)}}
+	if regions := keyscan.DataRegions(data); !reflect.DeepEqual(regions, want) {
+		t.Errorf("data regions of non-executable data were %v, want: %v", regions, want)
+	}
+
+	execFile, err := os.Executable()
+	if err != nil {
+		t.Skip("test executable not available:", err)
+	}
+	exec, err := os.ReadFile(execFile)
+	if err != nil {
+		t.Skip("test executable not readable:", err)
+	}
+	regions := keyscan.DataRegions(exec)
+	total := 0
+	for _, r := range regions {
+		if r.Offset < 0 || r.End() > len(exec) {
+			t.Errorf("data region %v exceeds executable file size %d", r, len(exec))
+		}
+		total += r.Size
+	}
+	if len(regions) == 0 || total >= len(exec) {
+		t.Errorf("data regions of test executable cover %d of %d bytes, want: only the data sections", total, len(exec))
+	}
+}
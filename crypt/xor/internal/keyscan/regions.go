@@ -0,0 +1,129 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package keyscan
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+)
+
+// Region is a range of bytes within an executable file.
+type Region struct {
+	Offset int
+	Size   int
+}
+
+// End returns the offset after the last byte of the region.
+func (r Region) End() int {
+	return r.Offset + r.Size
+}
+
+// Mach-O section flags
+const (
+	machoSectionType      = 0xFF
+	machoZeroFill         = 0x1
+	machoPureInstructions = 0x80000000
+	machoSomeInstructions = 0x00000400
+	machoInstructions     = machoPureInstructions | machoSomeInstructions
+)
+
+// peCode are the PE section characteristics of code sections.
+const peCode = pe.IMAGE_SCN_MEM_EXECUTE | pe.IMAGE_SCN_CNT_CODE
+
+// DataRegions returns the regions of an ELF, PE or Mach-O executable file
+// that contain initialized data, which is where key material is stored.
+// Code sections are excluded. If the data is not an executable file in one
+// of these formats, the whole data is returned as a single region.
+func DataRegions(data []byte) []Region {
+	var regions []Region
+	switch {
+	case bytes.HasPrefix(data, []byte(elf.ELFMAG)):
+		regions = elfDataRegions(data)
+	case bytes.HasPrefix(data, []byte("MZ")):
+		regions = peDataRegions(data)
+	default:
+		regions = machoDataRegions(data)
+	}
+	if regions == nil {
+		return []Region{{Offset: 0, Size: len(data)}}
+	}
+	return regions
+}
+
+func elfDataRegions(data []byte) []Region {
+	f, err := elf.NewFile(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	var regions []Region
+	for _, s := range f.Sections {
+		if s.Type != elf.SHT_PROGBITS ||
+			s.Flags&elf.SHF_ALLOC == 0 ||
+			s.Flags&elf.SHF_EXECINSTR != 0 ||
+			s.Flags&elf.SHF_COMPRESSED != 0 {
+			continue
+		}
+		regions = appendRegion(regions, data, 0, s.Offset, s.Size)
+	}
+	return regions
+}
+
+func peDataRegions(data []byte) []Region {
+	f, err := pe.NewFile(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	var regions []Region
+	for _, s := range f.Sections {
+		if s.Characteristics&peCode != 0 ||
+			s.Characteristics&pe.IMAGE_SCN_CNT_INITIALIZED_DATA == 0 {
+			continue
+		}
+		regions = appendRegion(regions, data, 0, uint64(s.Offset), uint64(s.Size))
+	}
+	return regions
+}
+
+func machoDataRegions(data []byte) []Region {
+	if fat, err := macho.NewFatFile(bytes.NewReader(data)); err == nil {
+		var regions []Region
+		for _, arch := range fat.Arches {
+			regions = appendMachoRegions(regions, data, uint64(arch.Offset), arch.File)
+		}
+		return regions
+	}
+	f, err := macho.NewFile(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	return appendMachoRegions(nil, data, 0, f)
+}
+
+func appendMachoRegions(regions []Region, data []byte, base uint64, f *macho.File) []Region {
+	for _, s := range f.Sections {
+		if s.Flags&machoSectionType == machoZeroFill ||
+			s.Flags&machoInstructions != 0 ||
+			s.Offset == 0 {
+			continue
+		}
+		regions = appendRegion(regions, data, base, uint64(s.Offset), s.Size)
+	}
+	return regions
+}
+
+// appendRegion appends a region, clamped to the bounds of the data.
+func appendRegion(regions []Region, data []byte, base, offset, size uint64) []Region {
+	start := base + offset
+	if start >= uint64(len(data)) || size == 0 {
+		return regions
+	}
+	end := start + size
+	if end > uint64(len(data)) || end < start {
+		end = uint64(len(data))
+	}
+	return append(regions, Region{Offset: int(start), Size: int(end - start)})
+}
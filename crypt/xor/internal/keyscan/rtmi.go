@@ -0,0 +1,26 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package keyscan
+
+// Signatures of the short (256 bytes) and the long (65536 bytes) magic bytes
+// of the Return to Monkey Island XOR key.
+var (
+	RtMIMagicBytes1 = Signature{
+		Length:    256,
+		FirstByte: 0xD5,
+		Sum: [...]byte{
+			0xB1, 0x90, 0xC4, 0x21, 0xFE, 0x7F, 0xEA, 0xFC,
+			0x77, 0xC5, 0x17, 0xA2, 0x32, 0xAB, 0xBB, 0x4C,
+		},
+	}
+	RtMIMagicBytes2 = Signature{
+		Length:    65536,
+		FirstByte: 0xF7,
+		Sum: [...]byte{
+			0x7F, 0xAA, 0xF6, 0x57, 0x4F, 0x27, 0xEB, 0xD9,
+			0xD2, 0x74, 0x4C, 0xC6, 0x8E, 0x41, 0x15, 0xC8,
+		},
+	}
+)
@@ -7,6 +7,7 @@ package xor
 import (
 	"io"
 
+	"github.com/fzipp/gg/crypt/transform"
 	"github.com/fzipp/gg/crypt/xor/rtmi"
 	"github.com/fzipp/gg/crypt/xor/twp"
 	"github.com/fzipp/gg/ggdict"
@@ -17,23 +18,30 @@ type Key interface {
 	DecodingReader(r io.Reader, expectedSize int64) io.Reader
 	EncodingWriter(w io.Writer, expectedSize int64) io.Writer
 
+	// NewDecoder and NewEncoder return the transformers used by
+	// DecodingReader and EncodingWriter, e.g. for chaining them
+	// with other transformers.
+	NewDecoder(expectedSize int64) transform.Transformer
+	NewEncoder(expectedSize int64) transform.Transformer
+
 	// NeedsLoading returns true if the key needs to be loaded
-	// from the executable file via LoadFrom.
+	// from the executable file via Load or LoadKey.
 	NeedsLoading() bool
-	// LoadFrom loads the key from the executable file.
-	// This is only necessary if NeedsLoading returns true.
-	LoadFrom(execFile string) error
 
 	GGDictFormat() ggdict.Format
 }
 
-// KnownKeys is a collection of XOR keys for ggpack files found in the wild.
+// knownKeys is a collection of XOR keys for ggpack files found in the wild.
 //
 // For Thimbleweed Park multiple keys are known. They differ slightly at
 // MagicBytes[5] (0x5B vs. 0x56) and regarding the multiplier (0x6D vs. 0xAD).
 // This is reflected in the names (e.g. "thimbleweed-56ad") by which they can
 // be referenced.
-var KnownKeys = map[string]Key{
+//
+// Further keys can be added via RegisterKey or RegisterKeyFile. The keys
+// are accessed via LookupKey, KeyNames and Keys, which are safe for
+// concurrent use with the registration.
+var knownKeys = map[string]Key{
 	// Thimbleweed Park
 	"thimbleweed": &twp.Key{
 		MagicBytes: []byte{
@@ -81,11 +89,11 @@ var KnownKeys = map[string]Key{
 	// Return to Monkey Island
 	"monkey": &rtmi.Key{
 		// The magic bytes of this key need to be loaded from
-		// the executable file via Key.LoadFrom.
+		// the executable file via Load or LoadKey.
 		Modifier: 0x78,
 	},
 }
 
 // It is the default key since the author of this package happens to have
 // only ggpack files encrypted with this key.
-var DefaultKey = KnownKeys["thimbleweed"]
+var DefaultKey = knownKeys["thimbleweed"]
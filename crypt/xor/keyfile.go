@@ -0,0 +1,193 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xor
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/fzipp/gg/crypt/xor/rtmi"
+	"github.com/fzipp/gg/crypt/xor/twp"
+	"github.com/fzipp/gg/ggdict"
+)
+
+// KeyFile is the content of a key file, a JSON object that maps key names
+// to key definitions, e.g.
+//
+//	{
+//	  "thimbleweed-1.0.958": {
+//	    "type": "twp",
+//	    "magicBytes": "4FD0A0AC4A56B9E5937945A5C1CB3193",
+//	    "multiplier": 173
+//	  },
+//	  "monkey-1.1": {
+//	    "type": "rtmi",
+//	    "modifier": 120,
+//	    "ggdictFormat": "monkey"
+//	  }
+//	}
+type KeyFile map[string]KeyDefinition
+
+// KeyDefinition defines an XOR key in a key file.
+type KeyDefinition struct {
+	// Type is the type of the key: "twp" for the XOR encryption of
+	// Thimbleweed Park and Delores, "rtmi" for the XOR encryption of
+	// Return to Monkey Island.
+	Type string `json:"type"`
+
+	// MagicBytes are the magic bytes of a "twp" key, at least 16.
+	MagicBytes HexBytes `json:"magicBytes,omitempty"`
+	// Multiplier is the multiplier of a "twp" key. It is required.
+	Multiplier *byte `json:"multiplier,omitempty"`
+
+	// MagicBytes1 and MagicBytes2 are the short (256 bytes) and the long
+	// (65536 bytes) magic bytes of an "rtmi" key. If they are omitted, they
+	// are loaded from the game's executable file.
+	MagicBytes1 HexBytes `json:"magicBytes1,omitempty"`
+	MagicBytes2 HexBytes `json:"magicBytes2,omitempty"`
+	// Modifier is the modifier of an "rtmi" key. It is required.
+	Modifier *byte `json:"modifier,omitempty"`
+
+	// GGDictFormat is the format of the GGDictionary data encrypted with
+	// the key: "thimbleweed" or "monkey". If it is omitted, the format of
+	// the game the key type was introduced with is used.
+	GGDictFormat string `json:"ggdictFormat,omitempty"`
+}
+
+// Key types of key definitions
+const (
+	KeyTypeTWP  = "twp"
+	KeyTypeRtMI = "rtmi"
+)
+
+var ggdictFormats = map[string]ggdict.Format{
+	"thimbleweed": ggdict.FormatThimbleweed,
+	"monkey":      ggdict.FormatMonkey,
+}
+
+// Key creates the key defined by the key definition.
+func (d KeyDefinition) Key() (Key, error) {
+	var key Key
+	switch d.Type {
+	case KeyTypeTWP:
+		if len(d.MagicBytes) < 16 {
+			return nil, fmt.Errorf("key of type %q needs at least 16 magic bytes, found %d", d.Type, len(d.MagicBytes))
+		}
+		if d.Multiplier == nil {
+			return nil, fmt.Errorf("key of type %q needs a multiplier", d.Type)
+		}
+		key = &twp.Key{MagicBytes: d.MagicBytes, Multiplier: *d.Multiplier}
+	case KeyTypeRtMI:
+		if err := checkLength("magicBytes1", d.MagicBytes1, 256); err != nil {
+			return nil, err
+		}
+		if err := checkLength("magicBytes2", d.MagicBytes2, 65536); err != nil {
+			return nil, err
+		}
+		if d.Modifier == nil {
+			return nil, fmt.Errorf("key of type %q needs a modifier", d.Type)
+		}
+		key = &rtmi.Key{MagicBytes1: d.MagicBytes1, MagicBytes2: d.MagicBytes2, Modifier: *d.Modifier}
+	default:
+		return nil, fmt.Errorf("unknown key type: %q", d.Type)
+	}
+	if d.GGDictFormat == "" {
+		return key, nil
+	}
+	format, ok := ggdictFormats[strings.ToLower(d.GGDictFormat)]
+	if !ok {
+		return nil, fmt.Errorf("unknown GGDictionary format: %q", d.GGDictFormat)
+	}
+	return &keyWithFormat{Key: key, format: format}, nil
+}
+
+func checkLength(name string, b HexBytes, length int) error {
+	if b != nil && len(b) != length {
+		return fmt.Errorf("%s must be %d bytes long, found %d", name, length, len(b))
+	}
+	return nil
+}
+
+// keyWithFormat overrides the GGDictionary format of a key.
+type keyWithFormat struct {
+	Key
+	format ggdict.Format
+}
+
+func (k *keyWithFormat) GGDictFormat() ggdict.Format {
+	return k.format
+}
+
+// ReadKeyFile reads a key file and creates the defined keys.
+func ReadKeyFile(r io.Reader) (map[string]Key, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var file KeyFile
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("could not decode key file: %w", err)
+	}
+	keys := make(map[string]Key, len(file))
+	for name, def := range file {
+		key, err := def.Key()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", name, err)
+		}
+		keys[name] = key
+	}
+	return keys, nil
+}
+
+// RegisterKeyFile reads the key file at the given path and registers
+// the defined keys like RegisterKey. Either all keys are registered or,
+// if one of them is invalid or already registered, none. It returns the
+// names of the registered keys in sorted order.
+func RegisterKeyFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open key file: %w", err)
+	}
+	defer f.Close()
+	keys, err := ReadKeyFile(f)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if err := registerKeys(keys); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// HexBytes are bytes that are represented as a hexadecimal string in JSON,
+// e.g. "4FD0A0AC". Whitespace within the string is ignored.
+type HexBytes []byte
+
+func (b HexBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strings.ToUpper(hex.EncodeToString(b)))
+}
+
+func (b *HexBytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	s = strings.Join(strings.Fields(s), "")
+	decoded, err := hex.DecodeString(s)
+	if err != nil {
+		return errors.New("invalid hex bytes: " + err.Error())
+	}
+	*b = decoded
+	return nil
+}
@@ -0,0 +1,170 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xor_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fzipp/gg/crypt/xor"
+	"github.com/fzipp/gg/ggdict"
+)
+
+func TestReadKeyFile(t *testing.T) {
+	keyFile := `{
+		"custom-twp": {
+			"type": "twp",
+			"magicBytes": "4FD0A0AC 4A56B9E5 937945A5 C1CB3193",
+			"multiplier": 173
+		},
+		"custom-rtmi": {
+			"type": "rtmi",
+			"modifier": 120,
+			"ggdictFormat": "thimbleweed"
+		}
+	}`
+	keys, err := xor.ReadKeyFile(strings.NewReader(keyFile))
+	if err != nil {
+		t.Errorf("reading key file returned an error: %s", err)
+		return
+	}
+	tests := []struct {
+		name             string
+		wantNeedsLoading bool
+		wantFormat       ggdict.Format
+	}{
+		{"custom-twp", false, ggdict.FormatThimbleweed},
+		{"custom-rtmi", true, ggdict.FormatThimbleweed},
+	}
+	for _, tt := range tests {
+		key, ok := keys[tt.name]
+		if !ok {
+			t.Errorf("key %q not found in key file", tt.name)
+			continue
+		}
+		if key.NeedsLoading() != tt.wantNeedsLoading {
+			t.Errorf("NeedsLoading for key %q was %v, want: %v", tt.name, key.NeedsLoading(), tt.wantNeedsLoading)
+		}
+		if format := key.GGDictFormat(); !reflect.DeepEqual(format, tt.wantFormat) {
+			t.Errorf("GGDict format for key %q was %v, want: %v", tt.name, format, tt.wantFormat)
+		}
+	}
+	testWriterReaderRoundTrip(t, keys["custom-twp"])
+}
+
+func TestReadKeyFileErrors(t *testing.T) {
+	tests := []struct {
+		keyFile   string
+		wantError string
+	}{
+		{
+			`{"k": {"type": "aes"}}`,
+			`key "k": unknown key type: "aes"`,
+		},
+		{
+			`{"k": {"type": "twp", "magicBytes": "4FD0", "multiplier": 173}}`,
+			`key "k": key of type "twp" needs at least 16 magic bytes, found 2`,
+		},
+		{
+			`{"k": {"type": "twp", "magicBytes": "4FD0A0AC4A56B9E5937945A5C1CB3193"}}`,
+			`key "k": key of type "twp" needs a multiplier`,
+		},
+		{
+			`{"k": {"type": "rtmi"}}`,
+			`key "k": key of type "rtmi" needs a modifier`,
+		},
+		{
+			`{"k": {"type": "rtmi", "magicBytes1": "00", "modifier": 120}}`,
+			`key "k": magicBytes1 must be 256 bytes long, found 1`,
+		},
+		{
+			`{"k": {"type": "rtmi", "modifier": 120, "ggdictFormat": "delores"}}`,
+			`key "k": unknown GGDictionary format: "delores"`,
+		},
+		{
+			`{"k": {"type": "twp", "magicBytes": "XY"}}`,
+			`could not decode key file: invalid hex bytes: encoding/hex: invalid byte: U+0058 'X'`,
+		},
+	}
+	for _, tt := range tests {
+		_, err := xor.ReadKeyFile(strings.NewReader(tt.keyFile))
+		if err == nil {
+			t.Errorf("expected error for key file %s, but no error returned", tt.keyFile)
+			continue
+		}
+		if err.Error() != tt.wantError {
+			t.Errorf("error message for key file %s was: %q, want: %q", tt.keyFile, err.Error(), tt.wantError)
+		}
+	}
+}
+
+func TestRegisterKeyFile(t *testing.T) {
+	// The registry is global, so the key name must be unique for repeated
+	// test runs within the same process.
+	registerKeyFileRuns++
+	name := fmt.Sprintf("Test-RegisterKeyFile-%d", registerKeyFileRuns)
+	path := filepath.Join(t.TempDir(), "keys.json")
+	keyFile := `{
+		"` + name + `": {
+			"type": "twp",
+			"magicBytes": "00112233 44556677 8899AABB CCDDEEFF",
+			"multiplier": 109
+		}
+	}`
+	if err := os.WriteFile(path, []byte(keyFile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	names, err := xor.RegisterKeyFile(path)
+	if err != nil {
+		t.Errorf("registering key file returned an error: %s", err)
+		return
+	}
+	if want := []string{name}; !reflect.DeepEqual(names, want) {
+		t.Errorf("registered key names were %v, want: %v", names, want)
+	}
+	if _, ok := xor.LookupKey(strings.ToLower(name)); !ok {
+		t.Errorf("registered key not found by LookupKey")
+	}
+	_, err = xor.RegisterKeyFile(path)
+	wantError := fmt.Sprintf("key %q is already registered", strings.ToLower(name))
+	if err == nil || err.Error() != wantError {
+		t.Errorf("error for registering key file twice was: %v, want: %q", err, wantError)
+	}
+}
+
+func TestRegisterKeyFileAllOrNothing(t *testing.T) {
+	registerKeyFileRuns++
+	name := fmt.Sprintf("Test-RegisterKeyFileAllOrNothing-%d", registerKeyFileRuns)
+	path := filepath.Join(t.TempDir(), "keys.json")
+	keyFile := `{
+		"` + name + `": {
+			"type": "twp",
+			"magicBytes": "00112233 44556677 8899AABB CCDDEEFF",
+			"multiplier": 109
+		},
+		"Thimbleweed": {
+			"type": "twp",
+			"magicBytes": "00112233 44556677 8899AABB CCDDEEFF",
+			"multiplier": 109
+		}
+	}`
+	if err := os.WriteFile(path, []byte(keyFile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := xor.RegisterKeyFile(path)
+	wantError := `key "thimbleweed" is already registered`
+	if err == nil || err.Error() != wantError {
+		t.Errorf("error for registering key file was: %v, want: %q", err, wantError)
+	}
+	if _, ok := xor.LookupKey(strings.ToLower(name)); ok {
+		t.Errorf("key %q was registered, although the key file was rejected", name)
+	}
+}
+
+var registerKeyFileRuns int
@@ -0,0 +1,130 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fzipp/gg/crypt/xor/rtmi"
+)
+
+// loadedKeys caches keys loaded from executable files by the original
+// key and the path, size and modification time of the executable file.
+// It holds at most maxLoadedKeys keys; the oldest key is evicted first.
+var (
+	loadedKeysMu    sync.Mutex
+	loadedKeys      = map[loadedKeyID]Key{}
+	loadedKeysOrder []loadedKeyID
+)
+
+const maxLoadedKeys = 8
+
+type loadedKeyID struct {
+	key     Key
+	path    string
+	size    int64
+	modTime int64
+}
+
+func cachedKey(id loadedKeyID) (Key, bool) {
+	loadedKeysMu.Lock()
+	defer loadedKeysMu.Unlock()
+	key, ok := loadedKeys[id]
+	return key, ok
+}
+
+func cacheKey(id loadedKeyID, key Key) {
+	loadedKeysMu.Lock()
+	defer loadedKeysMu.Unlock()
+	if _, ok := loadedKeys[id]; ok {
+		return
+	}
+	if len(loadedKeysOrder) == maxLoadedKeys {
+		delete(loadedKeys, loadedKeysOrder[0])
+		loadedKeysOrder = loadedKeysOrder[1:]
+	}
+	loadedKeys[id] = key
+	loadedKeysOrder = append(loadedKeysOrder, id)
+}
+
+// LoadKey looks up the key with the given name via LookupKey and loads it
+// from the game's executable file via Load, if it needs loading.
+func LoadKey(name, execFile string) (Key, error) {
+	key, ok := LookupKey(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown key name: %q", name)
+	}
+	if !key.NeedsLoading() {
+		return key, nil
+	}
+	return Load(key, execFile)
+}
+
+// Load returns a copy of the key that is loaded from the game's executable
+// file. Keys that do not need loading are returned as is. The given key is
+// never modified, so a registered key can be loaded concurrently from the
+// executable files of different game builds.
+//
+// Loaded keys are cached by the path, size and modification time of the
+// executable file, so loading the same key from the same executable again
+// neither reads nor scans the file. The cache holds the keys of the last
+// few executable files.
+func Load(key Key, execFile string) (Key, error) {
+	if !key.NeedsLoading() {
+		return key, nil
+	}
+	if !canLoad(key) {
+		return nil, fmt.Errorf("key of type %T cannot be loaded", key)
+	}
+	path, err := filepath.Abs(execFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not read executable file: %w", err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read executable file: %w", err)
+	}
+	id := loadedKeyID{key: key, path: path, size: fi.Size(), modTime: fi.ModTime().UnixNano()}
+	if loaded, ok := cachedKey(id); ok {
+		return loaded, nil
+	}
+	exec, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read executable file: %w", err)
+	}
+	loaded, err := load(key, exec)
+	if err != nil {
+		return nil, fmt.Errorf("could not load key from executable file: %w", err)
+	}
+	cacheKey(id, loaded)
+	return loaded, nil
+}
+
+func load(key Key, exec []byte) (Key, error) {
+	switch k := key.(type) {
+	case *rtmi.Key:
+		return k.Load(exec)
+	case *keyWithFormat:
+		loaded, err := load(k.Key, exec)
+		if err != nil {
+			return nil, err
+		}
+		return &keyWithFormat{Key: loaded, format: k.format}, nil
+	}
+	return nil, fmt.Errorf("key of type %T cannot be loaded", key)
+}
+
+func canLoad(key Key) bool {
+	switch k := key.(type) {
+	case *rtmi.Key:
+		return true
+	case *keyWithFormat:
+		return canLoad(k.Key)
+	}
+	return false
+}
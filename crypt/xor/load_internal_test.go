@@ -0,0 +1,37 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xor
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fzipp/gg/crypt/xor/rtmi"
+)
+
+func TestLoadedKeysLimit(t *testing.T) {
+	key := &rtmi.Key{Modifier: 0x78}
+	ids := make([]loadedKeyID, maxLoadedKeys+1)
+	for i := range ids {
+		ids[i] = loadedKeyID{key: key, path: fmt.Sprintf("/games/build-%d/game.exe", i), size: 1024}
+		cacheKey(ids[i], &rtmi.Key{Modifier: byte(i)})
+	}
+	if _, ok := cachedKey(ids[0]); ok {
+		t.Errorf("oldest key is still cached after caching %d keys", len(ids))
+	}
+	for _, id := range ids[1:] {
+		if _, ok := cachedKey(id); !ok {
+			t.Errorf("key for %s is not cached", id.path)
+		}
+	}
+	if len(loadedKeys) > maxLoadedKeys {
+		t.Errorf("number of cached keys was %d, want: at most %d", len(loadedKeys), maxLoadedKeys)
+	}
+	changed := ids[len(ids)-1]
+	changed.modTime++
+	if _, ok := cachedKey(changed); ok {
+		t.Errorf("key is cached for a modified executable file")
+	}
+}
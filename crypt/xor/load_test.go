@@ -0,0 +1,71 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xor_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/fzipp/gg/crypt/xor"
+	"github.com/fzipp/gg/crypt/xor/rtmi"
+)
+
+func TestLoadKeyWithoutLoading(t *testing.T) {
+	key, err := xor.LoadKey("Thimbleweed", "does-not-exist.exe")
+	if err != nil {
+		t.Errorf("loading key that does not need loading returned an error: %s", err)
+		return
+	}
+	if key != mustLookupKey(t, "thimbleweed") {
+		t.Errorf("loading key that does not need loading returned %v, want: the registered key", key)
+	}
+}
+
+func TestLoadKeyErrors(t *testing.T) {
+	execFile := filepath.Join(t.TempDir(), "game.exe")
+	if err := os.WriteFile(execFile, make([]byte, 1024), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		execFile  string
+		wantError string
+	}{
+		{"unknown", execFile, `unknown key name: "unknown"`},
+		{"monkey", execFile, "could not load key from executable file: one or both keys could not be found"},
+	}
+	for _, tt := range tests {
+		_, err := xor.LoadKey(tt.name, tt.execFile)
+		if err == nil {
+			t.Errorf("expected error for loading key %q, but no error returned", tt.name)
+			continue
+		}
+		if err.Error() != tt.wantError {
+			t.Errorf("error message for loading key %q was: %q, want: %q", tt.name, err.Error(), tt.wantError)
+		}
+	}
+}
+
+func TestLoadDoesNotModifyKey(t *testing.T) {
+	execFile := filepath.Join(t.TempDir(), "game.exe")
+	if err := os.WriteFile(execFile, make([]byte, 1024), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	key := &rtmi.Key{Modifier: 0x78}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = xor.Load(key, execFile)
+		}()
+	}
+	wg.Wait()
+	if key.MagicBytes1 != nil || key.MagicBytes2 != nil || !key.NeedsLoading() {
+		t.Errorf("loading key modified the original key: %#v", key)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// registryMu guards knownKeys against concurrent registration
+// and lookup of keys.
+var registryMu sync.RWMutex
+
+// RegisterKey adds a key to the known keys under the given name, so that
+// it can be looked up by LookupKey. Key names are case-insensitive. It returns an
+// error if a key with the same name is already registered.
+func RegisterKey(name string, key Key) error {
+	return registerKeys(map[string]Key{name: key})
+}
+
+// registerKeys adds all given keys to the known keys, or none of them if one
+// of them cannot be registered.
+func registerKeys(keys map[string]Key) error {
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	lowerNames := make(map[string]bool, len(names))
+	for _, name := range names {
+		if name == "" {
+			return errors.New("empty key name")
+		}
+		if keys[name] == nil {
+			return fmt.Errorf("key %q is nil", name)
+		}
+		lower := strings.ToLower(name)
+		if _, dup := knownKeys[lower]; dup || lowerNames[lower] {
+			return fmt.Errorf("key %q is already registered", lower)
+		}
+		lowerNames[lower] = true
+	}
+	for _, name := range names {
+		knownKeys[strings.ToLower(name)] = keys[name]
+	}
+	return nil
+}
+
+// LookupKey returns the known or registered key with the given name.
+// Key names are case-insensitive.
+func LookupKey(name string) (key Key, ok bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	key, ok = knownKeys[strings.ToLower(name)]
+	return key, ok
+}
+
+// Keys returns a copy of all known and registered keys by name.
+func Keys() map[string]Key {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	keys := make(map[string]Key, len(knownKeys))
+	for name, key := range knownKeys {
+		keys[name] = key
+	}
+	return keys
+}
+
+// KeyNames returns the names of all known and registered keys
+// in sorted order.
+func KeyNames() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(knownKeys))
+	for name := range knownKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
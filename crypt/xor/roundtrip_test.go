@@ -13,7 +13,7 @@ import (
 )
 
 func TestWriterReaderRoundTrip(t *testing.T) {
-	for name, key := range xor.KnownKeys {
+	for name, key := range xor.Keys() {
 		if key.NeedsLoading() {
 			// don't test keys which need to be loaded from
 			// the executable file
@@ -25,6 +25,15 @@ func TestWriterReaderRoundTrip(t *testing.T) {
 	}
 }
 
+func mustLookupKey(tb testing.TB, name string) xor.Key {
+	tb.Helper()
+	key, ok := xor.LookupKey(name)
+	if !ok {
+		tb.Fatalf("key %q not found", name)
+	}
+	return key
+}
+
 func testWriterReaderRoundTrip(t *testing.T, key xor.Key) {
 	original := []byte("This is a test.")
 	encodedBuf := &bytes.Buffer{}
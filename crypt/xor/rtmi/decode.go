@@ -4,7 +4,7 @@
 
 package rtmi
 
-import "github.com/fzipp/gg/crypt/internal/transform"
+import "github.com/fzipp/gg/crypt/transform"
 
 type decoder struct {
 	key    *Key
@@ -16,9 +16,25 @@ func newDecoder(key *Key, expectedSize int64) transform.Transformer {
 }
 
 func (d *decoder) Transform(dst, src []byte) {
+	d.cursor = xorKeystream(d.key, d.cursor, dst, src)
+}
+
+// xorKeystream combines src with the keystream starting at the cursor
+// and returns the cursor after the last byte. Encoding and decoding are
+// the same operation. The magic bytes are converted to array pointers,
+// so that the indexing needs no bounds checks.
+func xorKeystream(key *Key, cursor uint16, dst, src []byte) uint16 {
+	if len(src) == 0 {
+		return cursor
+	}
+	mb1 := (*[256]byte)(key.MagicBytes1)
+	mb2 := (*[65536]byte)(key.MagicBytes2)
+	modifier := key.Modifier
+	dst = dst[:len(src)]
 	for i, b := range src {
-		x := b ^ d.key.MagicBytes1[((uint8(d.cursor)+(d.key.Modifier))&0xFF)] ^ d.key.MagicBytes2[d.cursor]
-		dst[i] = x
-		d.cursor = d.cursor + uint16(d.key.MagicBytes1[uint8(d.cursor&0xFF)])&0xFFFF
+		c := uint8(cursor)
+		dst[i] = b ^ mb1[c+modifier] ^ mb2[cursor]
+		cursor += uint16(mb1[c])
 	}
+	return cursor
 }
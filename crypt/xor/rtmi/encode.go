@@ -4,7 +4,7 @@
 
 package rtmi
 
-import "github.com/fzipp/gg/crypt/internal/transform"
+import "github.com/fzipp/gg/crypt/transform"
 
 type encoder struct {
 	key    *Key
@@ -15,10 +15,6 @@ func newEncoder(key *Key, expectedSize int64) transform.Transformer {
 	return &encoder{key: key, cursor: uint16(expectedSize) + uint16(key.Modifier)}
 }
 
-func (d *encoder) Transform(dst, src []byte) {
-	for i, b := range src {
-		x := b ^ d.key.MagicBytes1[((uint8(d.cursor)+(d.key.Modifier))&0xFF)] ^ d.key.MagicBytes2[d.cursor]
-		dst[i] = x
-		d.cursor = d.cursor + uint16(d.key.MagicBytes1[uint8(d.cursor&0xFF)])&0xFFFF
-	}
+func (e *encoder) Transform(dst, src []byte) {
+	e.cursor = xorKeystream(e.key, e.cursor, dst, src)
 }
@@ -7,12 +7,11 @@
 package rtmi
 
 import (
-	"crypto/md5"
 	"errors"
 	"io"
-	"os"
 
-	"github.com/fzipp/gg/crypt/internal/transform"
+	"github.com/fzipp/gg/crypt/transform"
+	"github.com/fzipp/gg/crypt/xor/internal/keyscan"
 	"github.com/fzipp/gg/ggdict"
 )
 
@@ -25,6 +24,16 @@ type Key struct {
 	Modifier    byte
 }
 
+// NewDecoder returns the transformer that decodes data of the given size.
+func (key *Key) NewDecoder(expectedSize int64) transform.Transformer {
+	return newDecoder(key, expectedSize)
+}
+
+// NewEncoder returns the transformer that encodes data of the given size.
+func (key *Key) NewEncoder(expectedSize int64) transform.Transformer {
+	return newEncoder(key, expectedSize)
+}
+
 func (key *Key) DecodingReader(r io.Reader, expectedSize int64) io.Reader {
 	return transform.NewReader(r, newDecoder(key, expectedSize))
 }
@@ -41,36 +50,22 @@ func (key *Key) NeedsLoading() bool {
 	return key.MagicBytes1 == nil || key.MagicBytes2 == nil
 }
 
-func (key *Key) LoadFrom(execFile string) error {
-	data, err := os.ReadFile(execFile)
-	if err != nil {
-		return err
-	}
-	key.MagicBytes1 = extractKey(data, 256, 0xD5, &[...]byte{
-		0xB1, 0x90, 0xC4, 0x21, 0xFE, 0x7F, 0xEA, 0xFC,
-		0x77, 0xC5, 0x17, 0xA2, 0x32, 0xAB, 0xBB, 0x4C,
-	})
-	key.MagicBytes2 = extractKey(data, 65536, 0xF7, &[...]byte{
-		0x7F, 0xAA, 0xF6, 0x57, 0x4F, 0x27, 0xEB, 0xD9,
-		0xD2, 0x74, 0x4C, 0xC6, 0x8E, 0x41, 0x15, 0xC8,
-	})
-	if key.NeedsLoading() {
-		return errors.New("one or both keys could not be found")
-	}
-	return nil
-}
-
-func extractKey(data []byte, length int, firstByte byte, md5sum *[16]byte) []byte {
-	for i := 0; i < len(data)-length; i++ {
-		if isMatch(data[i:], length, firstByte, md5sum) {
-			key := make([]byte, length)
-			copy(key, data[i:i+length])
-			return key
+// Load returns a copy of the key with the magic bytes extracted from
+// the contents of the game's executable file. The key itself is not
+// modified, so it can be shared between goroutines.
+func (key *Key) Load(exec []byte) (*Key, error) {
+	sigs := []keyscan.Signature{keyscan.RtMIMagicBytes1, keyscan.RtMIMagicBytes2}
+	loaded := &Key{Modifier: key.Modifier}
+	for _, m := range keyscan.ScanExecutable(exec, sigs) {
+		switch m.Signature {
+		case 0:
+			loaded.MagicBytes1 = m.Bytes(exec, sigs)
+		case 1:
+			loaded.MagicBytes2 = m.Bytes(exec, sigs)
 		}
 	}
-	return nil
-}
-
-func isMatch(data []byte, length int, firstByte byte, md5sum *[16]byte) bool {
-	return data[0] == firstByte && md5.Sum(data[:length]) == *md5sum
+	if loaded.NeedsLoading() {
+		return nil, errors.New("one or both keys could not be found")
+	}
+	return loaded, nil
 }
@@ -0,0 +1,194 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xor_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/fzipp/gg/crypt/transform/transformtest"
+	"github.com/fzipp/gg/crypt/xor"
+	"github.com/fzipp/gg/crypt/xor/rtmi"
+	"github.com/fzipp/gg/crypt/xor/twp"
+)
+
+// The reference implementations process one byte at a time, like the
+// original implementations. The optimized implementations must produce
+// the same bytes.
+
+func referenceTWPDecode(key *twp.Key, data []byte) []byte {
+	out := make([]byte, len(data))
+	var cursor byte
+	xorSum := byte(len(data))
+	for i, b := range data {
+		x := b ^ key.MagicBytes[cursor&0x0F] ^ cursor*key.Multiplier
+		out[i] = x ^ xorSum
+		xorSum = x
+		cursor++
+	}
+	return out
+}
+
+func referenceTWPEncode(key *twp.Key, data []byte) []byte {
+	out := make([]byte, len(data))
+	var cursor byte
+	xorSum := byte(len(data))
+	for i, b := range data {
+		x := b ^ xorSum
+		out[i] = x ^ key.MagicBytes[cursor&0x0F] ^ cursor*key.Multiplier
+		xorSum = x
+		cursor++
+	}
+	return out
+}
+
+func referenceRtMI(key *rtmi.Key, data []byte) []byte {
+	out := make([]byte, len(data))
+	cursor := uint16(len(data)) + uint16(key.Modifier)
+	for i, b := range data {
+		out[i] = b ^ key.MagicBytes1[((uint8(cursor)+(key.Modifier))&0xFF)] ^ key.MagicBytes2[cursor]
+		cursor = cursor + uint16(key.MagicBytes1[uint8(cursor&0xFF)])&0xFFFF
+	}
+	return out
+}
+
+func randomBytes(rnd *rand.Rand, n int) []byte {
+	b := make([]byte, n)
+	rnd.Read(b)
+	return b
+}
+
+func randomRtMIKey(rnd *rand.Rand) *rtmi.Key {
+	return &rtmi.Key{
+		MagicBytes1: randomBytes(rnd, 256),
+		MagicBytes2: randomBytes(rnd, 65536),
+		Modifier:    0x78,
+	}
+}
+
+func TestTransformsMatchReference(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	twpKeys := map[string]*twp.Key{
+		"thimbleweed": mustLookupKey(t, "thimbleweed").(*twp.Key),
+		"delores":     mustLookupKey(t, "delores").(*twp.Key),
+		"random":      {MagicBytes: randomBytes(rnd, 16), Multiplier: 0xE7},
+	}
+	rtmiKey := randomRtMIKey(rnd)
+	for _, size := range []int{0, 1, 7, 8, 9, 31, 256, 1000, 70000} {
+		data := randomBytes(rnd, size)
+		for name, key := range twpKeys {
+			testTransform(t, name, key, data, referenceTWPEncode(key, data), referenceTWPDecode(key, data))
+		}
+		want := referenceRtMI(rtmiKey, data)
+		testTransform(t, "rtmi", rtmiKey, data, want, want)
+	}
+}
+
+func testTransform(t *testing.T, name string, key xor.Key, data, wantEncoded, wantDecoded []byte) {
+	size := int64(len(data))
+	for _, chunkSize := range []int{3, 13, 64, 1 << 20} {
+		var encoded bytes.Buffer
+		w := key.EncodingWriter(&encoded, size)
+		for p := data; len(p) > 0; {
+			n := chunkSize
+			if n > len(p) {
+				n = len(p)
+			}
+			if _, err := w.Write(p[:n]); err != nil {
+				t.Errorf("%s: encoding writer returned an error: %s", name, err)
+				return
+			}
+			p = p[n:]
+		}
+		if !bytes.Equal(encoded.Bytes(), wantEncoded) {
+			t.Errorf("%s: encoding %d bytes in chunks of %d differs from the reference implementation", name, size, chunkSize)
+		}
+
+		r := key.DecodingReader(&chunkedReader{r: bytes.NewReader(data), chunkSize: chunkSize}, size)
+		decoded, err := io.ReadAll(r)
+		if err != nil {
+			t.Errorf("%s: decoding reader returned an error: %s", name, err)
+			return
+		}
+		if !bytes.Equal(decoded, wantDecoded) {
+			t.Errorf("%s: decoding %d bytes in chunks of %d differs from the reference implementation", name, size, chunkSize)
+		}
+	}
+}
+
+// chunkedReader returns at most chunkSize bytes per Read call.
+type chunkedReader struct {
+	r         io.Reader
+	chunkSize int
+}
+
+func (r *chunkedReader) Read(p []byte) (int, error) {
+	if len(p) > r.chunkSize {
+		p = p[:r.chunkSize]
+	}
+	return r.r.Read(p)
+}
+
+func BenchmarkDecodingReader(b *testing.B) {
+	benchmarkKeys(b, func(b *testing.B, key xor.Key, data []byte) {
+		buf := make([]byte, len(data))
+		for i := 0; i < b.N; i++ {
+			r := key.DecodingReader(bytes.NewReader(data), int64(len(data)))
+			if _, err := io.ReadFull(r, buf); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkEncodingWriter(b *testing.B) {
+	benchmarkKeys(b, func(b *testing.B, key xor.Key, data []byte) {
+		for i := 0; i < b.N; i++ {
+			w := key.EncodingWriter(io.Discard, int64(len(data)))
+			if _, err := w.Write(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func benchmarkKeys(b *testing.B, bench func(b *testing.B, key xor.Key, data []byte)) {
+	rnd := rand.New(rand.NewSource(1))
+	keys := []struct {
+		name string
+		key  xor.Key
+	}{
+		{"twp", mustLookupKey(b, "thimbleweed")},
+		{"rtmi", randomRtMIKey(rnd)},
+	}
+	for _, k := range keys {
+		for _, size := range []int{1 << 10, 1 << 20} {
+			data := randomBytes(rnd, size)
+			b.Run(fmt.Sprintf("%s/%d", k.name, size), func(b *testing.B) {
+				b.SetBytes(int64(size))
+				b.ReportAllocs()
+				bench(b, k.key, data)
+			})
+		}
+	}
+}
+
+func TestConformance(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	twpKey := mustLookupKey(t, "thimbleweed").(*twp.Key)
+	rtmiKey := randomRtMIKey(rnd)
+	codecs := map[string]transformtest.Codec{
+		"twp":  {NewEncoder: twpKey.NewEncoder, NewDecoder: twpKey.NewDecoder},
+		"rtmi": {NewEncoder: rtmiKey.NewEncoder, NewDecoder: rtmiKey.NewDecoder},
+	}
+	for name, codec := range codecs {
+		if err := transformtest.TestCodec(codec); err != nil {
+			t.Errorf("%s transformers do not conform:\n%s", name, err)
+		}
+	}
+}
@@ -4,23 +4,38 @@
 
 package twp
 
-import "github.com/fzipp/gg/crypt/internal/transform"
+import (
+	"encoding/binary"
+
+	"github.com/fzipp/gg/crypt/transform"
+)
 
 type decoder struct {
-	key    *Key
-	cursor byte
-	xorSum byte
+	keystream *keystream
+	cursor    byte
+	xorSum    byte
 }
 
 func newDecoder(key *Key, expectedSize int64) transform.Transformer {
-	return &decoder{key: key, xorSum: byte(expectedSize)}
+	return &decoder{keystream: key.keystream(), xorSum: byte(expectedSize)}
 }
 
+// Transform decodes eight bytes at a time: x = b ^ keystream, and each
+// decoded byte is x ^ the previous x.
 func (d *decoder) Transform(dst, src []byte) {
-	for i, b := range src {
-		x := b ^ d.key.MagicBytes[d.cursor&0x0F] ^ d.cursor*d.key.Multiplier
-		dst[i] = x ^ d.xorSum
-		d.xorSum = x
-		d.cursor++
+	ks, cursor, prev := d.keystream, d.cursor, d.xorSum
+	i := 0
+	for ; i+8 <= len(src); i += 8 {
+		x := binary.LittleEndian.Uint64(src[i:]) ^ binary.LittleEndian.Uint64(ks[cursor:])
+		binary.LittleEndian.PutUint64(dst[i:], x^(x<<8|uint64(prev)))
+		prev = byte(x >> 56)
+		cursor += 8
+	}
+	for ; i < len(src); i++ {
+		x := src[i] ^ ks[cursor]
+		dst[i] = x ^ prev
+		prev = x
+		cursor++
 	}
+	d.cursor, d.xorSum = cursor, prev
 }
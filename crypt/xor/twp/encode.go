@@ -4,23 +4,43 @@
 
 package twp
 
-import "github.com/fzipp/gg/crypt/internal/transform"
+import (
+	"encoding/binary"
+
+	"github.com/fzipp/gg/crypt/transform"
+)
 
 type encoder struct {
-	key    *Key
-	xorSum byte
-	cursor byte
+	keystream *keystream
+	xorSum    byte
+	cursor    byte
 }
 
 func newEncoder(key *Key, expectedSize int64) transform.Transformer {
-	return &encoder{key: key, xorSum: byte(expectedSize)}
+	return &encoder{keystream: key.keystream(), xorSum: byte(expectedSize)}
 }
 
+// Transform encodes eight bytes at a time: x is the running XOR of the
+// plaintext bytes, computed with a parallel prefix XOR within each word,
+// and each encoded byte is x ^ keystream.
 func (e *encoder) Transform(dst, src []byte) {
-	for i, b := range src {
-		x := b ^ e.xorSum
-		dst[i] = x ^ e.key.MagicBytes[e.cursor&0x0F] ^ e.cursor*e.key.Multiplier
-		e.xorSum = x
-		e.cursor++
+	ks, cursor, prev := e.keystream, e.cursor, e.xorSum
+	i := 0
+	for ; i+8 <= len(src); i += 8 {
+		x := binary.LittleEndian.Uint64(src[i:])
+		x ^= x << 8
+		x ^= x << 16
+		x ^= x << 32
+		x ^= uint64(prev) * 0x0101010101010101
+		binary.LittleEndian.PutUint64(dst[i:], x^binary.LittleEndian.Uint64(ks[cursor:]))
+		prev = byte(x >> 56)
+		cursor += 8
+	}
+	for ; i < len(src); i++ {
+		x := src[i] ^ prev
+		dst[i] = x ^ ks[cursor]
+		prev = x
+		cursor++
 	}
+	e.cursor, e.xorSum = cursor, prev
 }
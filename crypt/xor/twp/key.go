@@ -7,10 +7,9 @@
 package twp
 
 import (
-	"errors"
 	"io"
 
-	"github.com/fzipp/gg/crypt/internal/transform"
+	"github.com/fzipp/gg/crypt/transform"
 	"github.com/fzipp/gg/ggdict"
 )
 
@@ -20,6 +19,31 @@ type Key struct {
 	Multiplier byte
 }
 
+// keystream is the sequence of bytes that the XOR encryption combines
+// with the data, indexed by the cursor. Since the cursor is a byte, the
+// sequence repeats after 256 bytes. The first 7 bytes are appended at the
+// end, so that 8 bytes can be read at any cursor position.
+type keystream [256 + 7]byte
+
+func (key *Key) keystream() *keystream {
+	ks := &keystream{}
+	for i := range ks {
+		cursor := byte(i)
+		ks[i] = key.MagicBytes[cursor&0x0F] ^ cursor*key.Multiplier
+	}
+	return ks
+}
+
+// NewDecoder returns the transformer that decodes data of the given size.
+func (key *Key) NewDecoder(expectedSize int64) transform.Transformer {
+	return newDecoder(key, expectedSize)
+}
+
+// NewEncoder returns the transformer that encodes data of the given size.
+func (key *Key) NewEncoder(expectedSize int64) transform.Transformer {
+	return newEncoder(key, expectedSize)
+}
+
 func (key *Key) DecodingReader(r io.Reader, expectedSize int64) io.Reader {
 	return transform.NewReader(r, newDecoder(key, expectedSize))
 }
@@ -32,10 +56,6 @@ func (key *Key) NeedsLoading() bool {
 	return false
 }
 
-func (key *Key) LoadFrom(execFile string) error {
-	return errors.New("this Key does not need to be loaded")
-}
-
 func (key *Key) GGDictFormat() ggdict.Format {
 	return ggdict.FormatThimbleweed
 }
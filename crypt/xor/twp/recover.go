@@ -0,0 +1,175 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package twp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// KnownPlaintext is the start of data encrypted with a key, for which
+// some bytes of the plaintext are known.
+type KnownPlaintext struct {
+	// Ciphertext is the start of the encrypted data.
+	Ciphertext []byte
+	// Size is the total size of the encrypted data.
+	Size int64
+	// Plaintext is the expected plaintext. Only the bytes
+	// for which Known is true are used.
+	Plaintext []byte
+	Known     []bool
+}
+
+// known reports whether the plaintext byte at index i is known.
+func (kp *KnownPlaintext) known(i int) bool {
+	return i < len(kp.Ciphertext) && i < len(kp.Plaintext) && i < len(kp.Known) && kp.Known[i]
+}
+
+// magicBytesLen is the number of magic bytes used by the XOR encryption.
+const magicBytesLen = 16
+
+// RecoverKeys derives the keys that are consistent with the known
+// plaintext of the samples.
+//
+// Decoding a byte at index i yields x[i] = c[i] ^ M[i%16] ^ i*m, where M are
+// the magic bytes and m is the multiplier, and the plaintext byte is
+// p[i] = x[i] ^ x[i-1], with x[-1] being the low byte of the size. Each known
+// plaintext byte therefore fixes the XOR of two neighbouring magic bytes for
+// a given multiplier, and a known first byte fixes M[0]. All multipliers are
+// tried; multipliers that contradict the known plaintext are rejected.
+//
+// For each key there is an equivalent key with the multiplier m+128, whose
+// odd magic bytes differ in the highest bit, since i*128 only flips the
+// highest bit for odd i. Both keys are returned.
+//
+// The samples must determine all magic bytes up to at most one undetermined
+// group of magic bytes, for which all 256 possibilities are returned.
+// The candidates should be verified by decoding data with them.
+func RecoverKeys(samples []KnownPlaintext) ([]*Key, error) {
+	var candidates []*Key
+	for m := 0; m < 256; m++ {
+		multiplier := byte(m)
+		s := newSolver()
+		consistent := true
+		for _, sample := range samples {
+			if !s.addSample(&sample, multiplier) {
+				consistent = false
+				break
+			}
+		}
+		if !consistent {
+			continue
+		}
+		free := s.freeGroups()
+		if len(free) > 1 {
+			return nil, fmt.Errorf("known plaintext is not sufficient: %d groups of magic bytes are undetermined", len(free))
+		}
+		if len(free) == 0 {
+			candidates = append(candidates, &Key{MagicBytes: s.magicBytes(), Multiplier: multiplier})
+			continue
+		}
+		for v := 0; v < 256; v++ {
+			s.fix(free[0], byte(v))
+			candidates = append(candidates, &Key{MagicBytes: s.magicBytes(), Multiplier: multiplier})
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, errors.New("no key is consistent with the known plaintext")
+	}
+	return candidates, nil
+}
+
+// solver solves XOR relations between the magic bytes with a union-find
+// structure. Node magicBytesLen is the constant zero, so that relations to
+// it fix the value of a magic byte.
+type solver struct {
+	parent [magicBytesLen + 1]int
+	// rel is the XOR of a node's value and its parent's value.
+	rel [magicBytesLen + 1]byte
+}
+
+const zeroNode = magicBytesLen
+
+func newSolver() *solver {
+	s := &solver{}
+	for i := range s.parent {
+		s.parent[i] = i
+	}
+	return s
+}
+
+func (s *solver) addSample(kp *KnownPlaintext, multiplier byte) bool {
+	c := kp.Ciphertext
+	if kp.known(0) {
+		// x[0] = p[0] ^ size, so M[0] = c[0] ^ p[0] ^ size
+		if !s.relate(0, zeroNode, c[0]^kp.Plaintext[0]^byte(kp.Size)) {
+			return false
+		}
+	}
+	for i := 1; i < len(c); i++ {
+		if !kp.known(i) {
+			continue
+		}
+		cursor, prev := byte(i), byte(i-1)
+		d := c[i] ^ c[i-1] ^ kp.Plaintext[i] ^ cursor*multiplier ^ prev*multiplier
+		if !s.relate(int(prev&0x0F), int(cursor&0x0F), d) {
+			return false
+		}
+	}
+	return true
+}
+
+// find returns the root of a node and the XOR of the node's value
+// and the root's value.
+func (s *solver) find(n int) (root int, rel byte) {
+	for s.parent[n] != n {
+		rel ^= s.rel[n]
+		n = s.parent[n]
+	}
+	return n, rel
+}
+
+// relate records that the XOR of the values of nodes a and b is d.
+// It reports whether this is consistent with the previous relations.
+func (s *solver) relate(a, b int, d byte) bool {
+	ra, xa := s.find(a)
+	rb, xb := s.find(b)
+	if ra == rb {
+		return xa^xb == d
+	}
+	if rb == zeroNode {
+		ra, rb = rb, ra
+	}
+	// The zero node always stays a root.
+	s.parent[rb] = ra
+	s.rel[rb] = xa ^ xb ^ d
+	return true
+}
+
+// freeGroups returns the roots of the groups of magic bytes
+// that are not related to the zero node.
+func (s *solver) freeGroups() []int {
+	var free []int
+	for i := 0; i < magicBytesLen; i++ {
+		if r, _ := s.find(i); r == i {
+			free = append(free, i)
+		}
+	}
+	return free
+}
+
+// fix sets the value of the root of a free group.
+func (s *solver) fix(root int, v byte) {
+	s.parent[root] = zeroNode
+	s.rel[root] = v
+}
+
+func (s *solver) magicBytes() []byte {
+	mb := make([]byte, magicBytesLen)
+	for i := range mb {
+		_, mb[i] = s.find(i)
+	}
+	return mb
+}
@@ -0,0 +1,51 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xxtea
+
+import (
+	"crypto/cipher"
+	"fmt"
+)
+
+type block struct {
+	key       Key
+	blockSize int
+}
+
+// NewCipher returns a cipher.Block that encrypts and decrypts blocks of
+// the given size with the key. Since XXTEA encrypts a variable number of
+// words as a single block, the block size can be chosen freely, but it must
+// be a multiple of 4 bytes and at least 8 bytes.
+func NewCipher(k Key, blockSize int) (cipher.Block, error) {
+	if blockSize < 2*wordSize || blockSize%wordSize != 0 {
+		return nil, fmt.Errorf("invalid block size %d: must be a multiple of %d and at least %d", blockSize, wordSize, 2*wordSize)
+	}
+	return &block{key: k, blockSize: blockSize}, nil
+}
+
+func (b *block) BlockSize() int {
+	return b.blockSize
+}
+
+func (b *block) Encrypt(dst, src []byte) {
+	EncryptInPlace(b.prepare(dst, src), b.key)
+}
+
+func (b *block) Decrypt(dst, src []byte) {
+	DecryptInPlace(b.prepare(dst, src), b.key)
+}
+
+// prepare copies the block from src to dst, which may overlap entirely.
+func (b *block) prepare(dst, src []byte) []byte {
+	if len(src) < b.blockSize {
+		panic("xxtea: input not full block")
+	}
+	if len(dst) < b.blockSize {
+		panic("xxtea: output not full block")
+	}
+	dst = dst[:b.blockSize]
+	copy(dst, src[:b.blockSize])
+	return dst
+}
@@ -10,19 +10,19 @@ const wordSize = 4
 
 var endianness = binary.LittleEndian
 
-func bytesToWords(b []byte) (w []uint32, rest []byte) {
-	w = make([]uint32, len(b)/wordSize)
-	for i := range w {
-		w[i] = endianness.Uint32(b[i*wordSize : (i+1)*wordSize])
-	}
-	rest = b[len(b)-len(b)%wordSize:]
-	return w, rest
+// words provides access to the little-endian 32-bit words of a byte slice
+// without converting it. Trailing bytes that do not form a whole word are
+// not accessible.
+type words []byte
+
+func (w words) len() int {
+	return len(w) / wordSize
+}
+
+func (w words) get(i int) uint32 {
+	return endianness.Uint32(w[i*wordSize:])
 }
 
-func wordsToBytes(w []uint32) (b []byte) {
-	b = make([]byte, len(w)*wordSize)
-	for i := range w {
-		endianness.PutUint32(b[i*wordSize:], w[i])
-	}
-	return b
+func (w words) set(i int, v uint32) {
+	endianness.PutUint32(w[i*wordSize:], v)
 }
@@ -12,60 +12,76 @@ package xxtea
 // Key is a 128-bit key.
 type Key [4]uint32
 
+// Encrypt returns an encrypted copy of p. See EncryptInPlace.
 func Encrypt(p []byte, k Key) []byte {
-	v, rest := bytesToWords(p)
-	encrypt(v, k)
-	return append(wordsToBytes(v), rest...)
+	c := append([]byte(nil), p...)
+	EncryptInPlace(c, k)
+	return c
 }
 
+// Decrypt returns a decrypted copy of p. See DecryptInPlace.
 func Decrypt(p []byte, k Key) []byte {
-	v, rest := bytesToWords(p)
-	decrypt(v, k)
-	return append(wordsToBytes(v), rest...)
+	c := append([]byte(nil), p...)
+	DecryptInPlace(c, k)
+	return c
+}
+
+// EncryptInPlace encrypts p as a single block of little-endian 32-bit words
+// without allocating memory. If the length of p is not a multiple of the word
+// size, the trailing bytes are not encrypted. Data with fewer than two words
+// is not encrypted at all.
+func EncryptInPlace(p []byte, k Key) {
+	encrypt(words(p), k)
+}
+
+// DecryptInPlace decrypts p, which was encrypted by EncryptInPlace,
+// without allocating memory.
+func DecryptInPlace(p []byte, k Key) {
+	decrypt(words(p), k)
 }
 
 const delta = 0x9e3779b9
 
-func encrypt(v []uint32, k Key) {
-	n := len(v)
+func encrypt(v words, k Key) {
+	n := v.len()
 	if n <= 1 {
 		return
 	}
 	q := 6 + 52/n
 	sum := 0
-	z := v[n-1]
+	z := v.get(n - 1)
 	for ; q > 0; q-- {
 		sum += delta
 		e := (sum >> 2) & 3
 		for p := 0; p < n-1; p++ {
-			y := v[p+1]
-			v[p] += mx(y, z, sum, p, e, k)
-			z = v[p]
+			y := v.get(p + 1)
+			z = v.get(p) + mx(y, z, sum, p, e, k)
+			v.set(p, z)
 		}
-		y := v[0]
-		v[n-1] += mx(y, z, sum, n-1, e, k)
-		z = v[n-1]
+		y := v.get(0)
+		z = v.get(n-1) + mx(y, z, sum, n-1, e, k)
+		v.set(n-1, z)
 	}
 }
 
-func decrypt(v []uint32, k Key) {
-	n := len(v)
+func decrypt(v words, k Key) {
+	n := v.len()
 	if n <= 1 {
 		return
 	}
 	q := 6 + 52/n
 	sum := q * delta
-	y := v[0]
+	y := v.get(0)
 	for ; q > 0; q-- {
 		e := (sum >> 2) & 3
 		for p := n - 1; p > 0; p-- {
-			z := v[p-1]
-			v[p] -= mx(y, z, sum, p, e, k)
-			y = v[p]
+			z := v.get(p - 1)
+			y = v.get(p) - mx(y, z, sum, p, e, k)
+			v.set(p, y)
 		}
-		z := v[n-1]
-		v[0] -= mx(y, z, sum, 0, e, k)
-		y = v[0]
+		z := v.get(n - 1)
+		y = v.get(0) - mx(y, z, sum, 0, e, k)
+		v.set(0, y)
 		sum -= delta
 	}
 }
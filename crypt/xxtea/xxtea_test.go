@@ -87,3 +87,94 @@ func TestDecrypt(t *testing.T) {
 		}
 	}
 }
+
+func TestEncryptDecryptInPlace(t *testing.T) {
+	tests := []struct {
+		input []byte
+		want  []byte
+	}{
+		{
+			[]byte("hello, world"),
+			[]byte{
+				0x54, 0xC3, 0xFB, 0xB8, 0xF5, 0xAA,
+				0x3F, 0x3C, 0x5B, 0x91, 0xC3, 0x98,
+			},
+		},
+		{
+			[]byte("abcdefg"),
+			[]byte("abcdefg"),
+		},
+		{
+			[]byte("abcdefghij"),
+			[]byte{0x9D, 0x5F, 0x1C, 0x05, 0xEB, 0x20, 0xB4, 0x4A, 0x69, 0x6A},
+		},
+	}
+	for _, tt := range tests {
+		buf := append([]byte(nil), tt.input...)
+		xxtea.EncryptInPlace(buf, key)
+		if !reflect.DeepEqual(buf, tt.want) {
+			t.Errorf("in-place xxtea encryption of %q, got: %X, want: %X", tt.input, buf, tt.want)
+		}
+		xxtea.DecryptInPlace(buf, key)
+		if !reflect.DeepEqual(buf, tt.input) {
+			t.Errorf("in-place xxtea decryption of %X, got: %q, want: %q", tt.want, buf, tt.input)
+		}
+	}
+}
+
+func TestCipher(t *testing.T) {
+	block, err := xxtea.NewCipher(key, 8)
+	if err != nil {
+		t.Errorf("creating cipher returned an error: %s", err)
+		return
+	}
+	if block.BlockSize() != 8 {
+		t.Errorf("block size was %d, want: 8", block.BlockSize())
+	}
+	dst := make([]byte, 8)
+	block.Encrypt(dst, []byte("abcdefgh"))
+	want := []byte{0x9D, 0x5F, 0x1C, 0x05, 0xEB, 0x20, 0xB4, 0x4A}
+	if !reflect.DeepEqual(dst, want) {
+		t.Errorf("cipher block encryption, got: %X, want: %X", dst, want)
+	}
+	block.Decrypt(dst, dst)
+	if string(dst) != "abcdefgh" {
+		t.Errorf("cipher block decryption, got: %q, want: %q", dst, "abcdefgh")
+	}
+
+	for _, blockSize := range []int{0, 4, 10} {
+		if _, err := xxtea.NewCipher(key, blockSize); err == nil {
+			t.Errorf("expected error for creating cipher with block size %d, but no error returned", blockSize)
+		}
+	}
+}
+
+func BenchmarkEncryptInPlace(b *testing.B) {
+	buf := make([]byte, 500_016)
+	b.SetBytes(int64(len(buf)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		xxtea.EncryptInPlace(buf, key)
+	}
+}
+
+func BenchmarkDecryptInPlace(b *testing.B) {
+	buf := make([]byte, 500_016)
+	b.SetBytes(int64(len(buf)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		xxtea.DecryptInPlace(buf, key)
+	}
+}
+
+func BenchmarkDecrypt(b *testing.B) {
+	buf := make([]byte, 500_016)
+	b.SetBytes(int64(len(buf)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		xxtea.Decrypt(buf, key)
+	}
+}
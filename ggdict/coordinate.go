@@ -0,0 +1,18 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ggdict
+
+// Coordinate types are string values with their own type markers in
+// dictionaries of the FormatMonkey format. Unmarshal returns them as these
+// types, so that they are written back with the same type markers.
+// In formats without coordinate types they are written as plain strings.
+type (
+	// Coordinate is a point, e.g. "{213,118}".
+	Coordinate string
+	// CoordinatePair is a pair of points, e.g. "{{-23,-20},{17,20}}".
+	CoordinatePair string
+	// CoordinateList is a list of points, e.g. "{82,94};{134,94};{142,91}".
+	CoordinateList string
+)
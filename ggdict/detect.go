@@ -0,0 +1,63 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ggdict
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DetectFormat determines the format of GGDictionary data by decoding it
+// with the layout of each known format. A layout is valid if the data can be
+// decoded with it and the root dictionary does not overlap with the string
+// offsets table. Coordinate type markers are only valid in formats with
+// coordinate types. A layout where the root dictionary ends exactly where the
+// string offsets table begins is preferred.
+//
+// If the data is valid in more than one format, as is the case for
+// dictionaries that do not reference any strings, FormatThimbleweed is
+// returned, since the data decodes to the same values in either format.
+func DetectFormat(data []byte) (Format, error) {
+	var (
+		candidate *Format
+		firstErr  error
+	)
+	for _, f := range []Format{FormatThimbleweed, FormatMonkey} {
+		exact, err := validateLayout(data, f)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if exact {
+			return f, nil
+		}
+		if candidate == nil {
+			fc := f
+			candidate = &fc
+		}
+	}
+	if candidate != nil {
+		return *candidate, nil
+	}
+	return Format{}, fmt.Errorf("unknown GGDictionary format: %w", firstErr)
+}
+
+// validateLayout checks if data is valid in format f. It reports whether
+// the root dictionary ends exactly where the string offsets table begins.
+func validateLayout(data []byte, f Format) (exact bool, err error) {
+	_, u, err := unmarshal(data, f, unmarshalOptions{})
+	if err != nil {
+		return false, err
+	}
+	if u.offset > u.stringOffsetsStart {
+		return false, errors.New("root dictionary overlaps string offsets table")
+	}
+	if u.hasCoordinates && !f.CoordinateTypes {
+		return false, errors.New("unexpected coordinate type")
+	}
+	return u.offset == u.stringOffsetsStart, nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ggdict_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/fzipp/gg/ggdict"
+)
+
+func TestDetectFormat(t *testing.T) {
+	dicts := []map[string]any{
+		nil,
+		{"name": "Test"},
+		{
+			"name":    "Test",
+			"count":   4,
+			"numbers": []any{0.5, 3, 2.6, 1.4},
+			"subobject": map[string]any{
+				"title": "Test 2",
+				"id":    0,
+			},
+			"nothing": nil,
+		},
+		{
+			"pos":     ggdict.Coordinate("{1,2}"),
+			"polygon": ggdict.CoordinateList("{1,2};{3,4}"),
+		},
+	}
+	formats := []struct {
+		name   string
+		format ggdict.Format
+	}{
+		{"thimbleweed", ggdict.FormatThimbleweed},
+		{"monkey", ggdict.FormatMonkey},
+	}
+	for _, dict := range dicts {
+		for _, f := range formats {
+			data, err := ggdict.Marshal(dict, f.format)
+			if err != nil {
+				t.Errorf("marshalling %#v in format %s returned an error: %s", dict, f.name, err)
+				continue
+			}
+			detected, err := ggdict.DetectFormat(data)
+			if err != nil {
+				t.Errorf("format detection for %#v in format %s returned an error: %s", dict, f.name, err)
+				continue
+			}
+			got, err := ggdict.Unmarshal(data, detected)
+			if err != nil {
+				t.Errorf("unmarshalling %#v in detected format %#v returned an error: %s", dict, detected, err)
+				continue
+			}
+			want, _ := ggdict.Unmarshal(data, f.format)
+			if !equalDicts(got, want) {
+				t.Errorf("unmarshalling %#v in detected format %#v resulted in %#v, want: %#v", dict, detected, got, want)
+			}
+			if len(dict) > 0 && detected != f.format {
+				t.Errorf("detected format for %#v in format %s was %#v, want: %#v", dict, f.name, detected, f.format)
+			}
+		}
+	}
+}
+
+func TestDetectFormatInexactLayout(t *testing.T) {
+	dict := map[string]any{"name": "Delores", "items": []any{"pen", "notebook"}}
+	data, err := ggdict.Marshal(dict, ggdict.FormatThimbleweed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Insert a padding byte before the string offsets table, so that the
+	// root dictionary does not end exactly where the table begins.
+	start := binary.LittleEndian.Uint32(data[8:])
+	padded := append(append(append([]byte{}, data[:start]...), 0), data[start:]...)
+	binary.LittleEndian.PutUint32(padded[8:], start+1)
+	for i := int(start) + 2; ; i += 4 {
+		offset := binary.LittleEndian.Uint32(padded[i:])
+		if offset == 0xFFFFFFFF {
+			break
+		}
+		binary.LittleEndian.PutUint32(padded[i:], offset+1)
+	}
+
+	detected, err := ggdict.DetectFormat(padded)
+	if err != nil {
+		t.Fatalf("detecting format of padded data failed: %v", err)
+	}
+	if detected != ggdict.FormatThimbleweed {
+		t.Errorf("detected format for padded data was %#v, want: %#v", detected, ggdict.FormatThimbleweed)
+	}
+	got, err := ggdict.Unmarshal(padded, detected)
+	if err != nil {
+		t.Fatalf("unmarshalling padded data failed: %v", err)
+	}
+	if !equalDicts(got, dict) {
+		t.Errorf("unmarshalled padded data was %#v, want: %#v", got, dict)
+	}
+}
+
+func TestDetectFormatErrors(t *testing.T) {
+	tests := []struct {
+		data      []byte
+		wantError string
+	}{
+		{[]byte{}, "unknown GGDictionary format: unexpected end of data"},
+		{[]byte{0x4, 0x3, 0x2, 0x1}, "unknown GGDictionary format: invalid format signature: 0x1020304"},
+		{[]byte{
+			0x1, 0x2, 0x3, 0x4, // format signature
+			0x1, 0x0, 0x0, 0x0, // always 1
+			0x12, 0x0, 0x0, 0x0, // string offsets start offset (18)
+			0x2,                // dictionary type start marker
+			0x1, 0x0, 0x0, 0x0, // length of dictionary (1), but no entries
+			0x2,                    // dictionary end marker
+			0x7,                    // string offsets start marker
+			0xff, 0xff, 0xff, 0xff, // string offsets end marker
+			0x8, // no strings
+		}, "unknown GGDictionary format: string index out of range: 4294903554"},
+	}
+	for _, tt := range tests {
+		_, err := ggdict.DetectFormat(tt.data)
+		if err == nil {
+			t.Errorf("expected error for format detection of %#v, but no error returned", tt.data)
+			continue
+		}
+		if err.Error() != tt.wantError {
+			t.Errorf("error message for format detection of %#v was: %q, want: %q", tt.data, err.Error(), tt.wantError)
+		}
+	}
+}
+
+func equalDicts(a, b map[string]any) bool {
+	x, _ := ggdict.MarshalText(a)
+	y, _ := ggdict.MarshalText(b)
+	return string(x) == string(y)
+}
@@ -0,0 +1,135 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ggdict
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ChangeKind is the kind of a Change.
+type ChangeKind int
+
+const (
+	// Added means that a value was added.
+	Added ChangeKind = iota + 1
+	// Removed means that a value was removed.
+	Removed
+	// Modified means that a value was replaced by a different value.
+	Modified
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// Change is a difference between two dictionaries at a path.
+// Old is nil for added values, New is nil for removed values.
+type Change struct {
+	Kind ChangeKind
+	Path Path
+	Old  any
+	New  any
+}
+
+// String formats the change as a single line, e.g.
+//
+//	~ objects[3].zsort: 4 -> 5
+//
+// with a prefix of "+" for added, "-" for removed and "~" for modified
+// values. The values are formatted in the text format.
+func (c Change) String() string {
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("+ %s: %s", c.Path, formatValue(c.New))
+	case Removed:
+		return fmt.Sprintf("- %s: %s", c.Path, formatValue(c.Old))
+	}
+	return fmt.Sprintf("~ %s: %s -> %s", c.Path, formatValue(c.Old), formatValue(c.New))
+}
+
+// Diff returns the changes that turn dictionary a into dictionary b.
+// Nested dictionaries are compared key by key, arrays are compared index
+// by index. Values of different types, e.g. an int and a float, are
+// considered different even if they represent the same number.
+// The changes are ordered by path, with dictionary keys in sorted order.
+func Diff(a, b map[string]any) []Change {
+	var changes []Change
+	diffDictionaries(&changes, Path{}, a, b)
+	return changes
+}
+
+func diffValues(changes *[]Change, p Path, a, b any) {
+	switch x := a.(type) {
+	case map[string]any:
+		if y, ok := b.(map[string]any); ok {
+			diffDictionaries(changes, p, x, y)
+			return
+		}
+	case []any:
+		if y, ok := b.([]any); ok {
+			diffArrays(changes, p, x, y)
+			return
+		}
+	}
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, Change{Kind: Modified, Path: p, Old: a, New: b})
+	}
+}
+
+func diffDictionaries(changes *[]Change, p Path, a, b map[string]any) {
+	for _, k := range unionKeys(a, b) {
+		x, inA := a[k]
+		y, inB := b[k]
+		switch {
+		case !inA:
+			*changes = append(*changes, Change{Kind: Added, Path: appendPath(p, k), New: y})
+		case !inB:
+			*changes = append(*changes, Change{Kind: Removed, Path: appendPath(p, k), Old: x})
+		default:
+			diffValues(changes, appendPath(p, k), x, y)
+		}
+	}
+}
+
+func diffArrays(changes *[]Change, p Path, a, b []any) {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		switch {
+		case i >= len(a):
+			*changes = append(*changes, Change{Kind: Added, Path: appendPath(p, i), New: b[i]})
+		case i >= len(b):
+			*changes = append(*changes, Change{Kind: Removed, Path: appendPath(p, i), Old: a[i]})
+		default:
+			diffValues(changes, appendPath(p, i), a[i], b[i])
+		}
+	}
+}
+
+// unionKeys returns the keys of all given dictionaries in sorted order.
+func unionKeys(dicts ...map[string]any) []string {
+	union := make(map[string]any)
+	for _, d := range dicts {
+		for k := range d {
+			union[k] = nil
+		}
+	}
+	return sortedKeys(union)
+}
+
+// appendPath returns a new path with elem appended to p,
+// without modifying the underlying array of p.
+func appendPath(p Path, elem any) Path {
+	q := make(Path, len(p), len(p)+1)
+	copy(q, p)
+	return append(q, elem)
+}
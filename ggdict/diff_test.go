@@ -0,0 +1,84 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ggdict_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fzipp/gg/ggdict"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b map[string]any
+		want []string
+	}{
+		{"equal", testDict(), testDict(), nil},
+		{"nil and empty", nil, map[string]any{}, nil},
+		{
+			"modified",
+			testDict(),
+			map[string]any{
+				"name": "Room",
+				"objects": []any{
+					map[string]any{"name": "door", "zsort": 3.0},
+					map[string]any{"name": "rope", "zsort": 5},
+				},
+			},
+			[]string{
+				"~ objects[0].zsort: 3 -> 3.0",
+				"~ objects[1].zsort: 4 -> 5",
+			},
+		},
+		{
+			"added and removed",
+			testDict(),
+			map[string]any{
+				"objects": []any{
+					map[string]any{"name": "door", "zsort": 3, "pos": ggdict.Coordinate("{1,2}")},
+					map[string]any{"name": "rope", "zsort": 4},
+					map[string]any{"name": "key"},
+				},
+			},
+			[]string{
+				"- name: \"Room\"",
+				"+ objects[0].pos: coord(\"{1,2}\")",
+				"+ objects[2]: {\n  name: \"key\"\n}",
+			},
+		},
+		{
+			"type changed",
+			map[string]any{"a": []any{1, 2}},
+			map[string]any{"a": map[string]any{}},
+			[]string{"~ a: [1, 2] -> {}"},
+		},
+	}
+	for _, tt := range tests {
+		changes := ggdict.Diff(tt.a, tt.b)
+		var got []string
+		for _, c := range changes {
+			got = append(got, c.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: diff was %q, want: %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDiffChanges(t *testing.T) {
+	a := map[string]any{"a": 1, "b": []any{"x"}}
+	b := map[string]any{"a": 2, "c": nil}
+	changes := ggdict.Diff(a, b)
+	want := []ggdict.Change{
+		{Kind: ggdict.Modified, Path: ggdict.Path{"a"}, Old: 1, New: 2},
+		{Kind: ggdict.Removed, Path: ggdict.Path{"b"}, Old: []any{"x"}},
+		{Kind: ggdict.Added, Path: ggdict.Path{"c"}, New: nil},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("diff was %#v, want: %#v", changes, want)
+	}
+}
@@ -0,0 +1,312 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ggdict
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// JSONOptions are options for the conversion between the GGDictionary
+// format and JSON. The zero value converts like encoding/json: compact
+// output, sorted keys, coordinates as strings and numbers as float64 when
+// converting from JSON.
+type JSONOptions struct {
+	// Indent is the string used for each indentation level of the JSON
+	// output, e.g. two spaces. If Indent is empty, the output is compact.
+	Indent string
+
+	// AnnotateTypes writes coordinate values as type annotation objects,
+	//
+	//	{"$type": "coordinate", "$value": "{213,118}"}
+	//
+	// with the types "coordinate", "coordinatePair" and "coordinateList",
+	// so that they keep their type when converted back from JSON. When
+	// converting from JSON, objects with exactly these two keys are
+	// decoded as coordinate values; without AnnotateTypes they are kept
+	// as dictionaries.
+	AnnotateTypes bool
+
+	// PreserveNumbers keeps the distinction between integers and floats.
+	// Floats are written with a decimal point or an exponent, e.g. 2.0,
+	// and numbers with a decimal point or an exponent are decoded as
+	// floats, all other numbers as integers.
+	PreserveNumbers bool
+
+	// OriginalKeyOrder keeps the order of dictionary keys of the input
+	// instead of sorting them. It only applies to ToJSON and FromJSON,
+	// since the decoded dictionaries of MarshalJSON and UnmarshalJSON
+	// are unordered maps.
+	OriginalKeyOrder bool
+
+	// EncodeOptions are the options for encoding the GGDictionary data
+	// in FromJSON.
+	EncodeOptions MarshalOptions
+}
+
+// Keys of a type annotation object, e.g.
+// {"$type": "coordinate", "$value": "{213,118}"}
+const (
+	jsonKeyType  = "$type"
+	jsonKeyValue = "$value"
+)
+
+// Type names of type annotation objects
+const (
+	jsonTypeCoordinate     = "coordinate"
+	jsonTypeCoordinatePair = "coordinatePair"
+	jsonTypeCoordinateList = "coordinateList"
+)
+
+// ToJSON converts data in the GGDictionary format to JSON and writes it,
+// followed by a newline, to w.
+func ToJSON(w io.Writer, data []byte, f Format, opts JSONOptions) error {
+	root, _, err := unmarshal(data, f, unmarshalOptions{ordered: opts.OriginalKeyOrder})
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, root, opts)
+}
+
+// FromJSON reads JSON from r and converts it to the GGDictionary format.
+func FromJSON(r io.Reader, f Format, opts JSONOptions) ([]byte, error) {
+	root, err := readJSON(r, opts)
+	if err != nil {
+		return nil, err
+	}
+	return opts.EncodeOptions.marshal(nil, root, f)
+}
+
+// MarshalJSON encodes a decoded dictionary as JSON.
+func MarshalJSON(dict map[string]any, opts JSONOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, dict, opts); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// UnmarshalJSON decodes a dictionary from JSON.
+func UnmarshalJSON(data []byte, opts JSONOptions) (map[string]any, error) {
+	opts.OriginalKeyOrder = false
+	root, err := readJSON(bytes.NewReader(data), opts)
+	if err != nil {
+		return nil, err
+	}
+	return root.(map[string]any), nil
+}
+
+func writeJSON(w io.Writer, root any, opts JSONOptions) error {
+	v, err := toJSONValue(root, opts)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", opts.Indent)
+	return enc.Encode(v)
+}
+
+func readJSON(r io.Reader, opts JSONOptions) (any, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var root any
+	var err error
+	if opts.OriginalKeyOrder {
+		root, err = decodeOrderedJSON(dec)
+	} else {
+		err = dec.Decode(&root)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not decode JSON: %w", err)
+	}
+	root, err = fromJSONValue(root, opts)
+	if err != nil {
+		return nil, err
+	}
+	// The root may be null or another value that is not an object, or it
+	// may have been decoded from a type annotation object.
+	switch root.(type) {
+	case map[string]any, *orderedDict:
+	default:
+		return nil, errors.New("JSON root is not an object")
+	}
+	return root, nil
+}
+
+// decodeOrderedJSON decodes the next JSON value, with objects decoded as
+// *orderedDict to keep the order of their keys.
+func decodeOrderedJSON(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		d := newOrderedDict()
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeOrderedJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			d.set(keyTok.(string), v)
+		}
+		_, err := dec.Token()
+		return d, err
+	case json.Delim('['):
+		a := []any{}
+		for dec.More() {
+			v, err := decodeOrderedJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+		_, err := dec.Token()
+		return a, err
+	}
+	return tok, nil
+}
+
+func toJSONValue(value any, opts JSONOptions) (any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, x := range v {
+			jx, err := toJSONValue(x, opts)
+			if err != nil {
+				return nil, annotatePath(err, k)
+			}
+			m[k] = jx
+		}
+		return m, nil
+	case *orderedDict:
+		d := &orderedDict{keys: v.keys, values: make(map[string]any, len(v.values))}
+		for k, x := range v.values {
+			jx, err := toJSONValue(x, opts)
+			if err != nil {
+				return nil, annotatePath(err, k)
+			}
+			d.values[k] = jx
+		}
+		return d, nil
+	case []any:
+		a := make([]any, len(v))
+		for i, x := range v {
+			jx, err := toJSONValue(x, opts)
+			if err != nil {
+				return nil, annotatePath(err, i)
+			}
+			a[i] = jx
+		}
+		return a, nil
+	case float64:
+		return jsonFloat(v, opts)
+	case float32:
+		return jsonFloat(float64(v), opts)
+	case Coordinate:
+		return typedJSONValue(jsonTypeCoordinate, string(v), opts), nil
+	case CoordinatePair:
+		return typedJSONValue(jsonTypeCoordinatePair, string(v), opts), nil
+	case CoordinateList:
+		return typedJSONValue(jsonTypeCoordinateList, string(v), opts), nil
+	}
+	return value, nil
+}
+
+func jsonFloat(f float64, opts JSONOptions) (any, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, fmt.Errorf("unsupported float value: %s", formatFloatLiteral(f))
+	}
+	if !opts.PreserveNumbers {
+		return f, nil
+	}
+	return json.Number(formatFloatLiteral(f)), nil
+}
+
+func typedJSONValue(typeName, s string, opts JSONOptions) any {
+	if !opts.AnnotateTypes {
+		return s
+	}
+	return map[string]any{jsonKeyType: typeName, jsonKeyValue: s}
+}
+
+func fromJSONValue(value any, opts JSONOptions) (any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		if typeName, ok := v[jsonKeyType]; ok && len(v) == 2 && opts.AnnotateTypes {
+			return fromTypeAnnotation(typeName, v[jsonKeyValue])
+		}
+		for k, x := range v {
+			dx, err := fromJSONValue(x, opts)
+			if err != nil {
+				return nil, annotatePath(err, k)
+			}
+			v[k] = dx
+		}
+		return v, nil
+	case *orderedDict:
+		if typeName, ok := v.values[jsonKeyType]; ok && len(v.keys) == 2 && opts.AnnotateTypes {
+			return fromTypeAnnotation(typeName, v.values[jsonKeyValue])
+		}
+		for k, x := range v.values {
+			dx, err := fromJSONValue(x, opts)
+			if err != nil {
+				return nil, annotatePath(err, k)
+			}
+			v.values[k] = dx
+		}
+		return v, nil
+	case []any:
+		for i, x := range v {
+			dx, err := fromJSONValue(x, opts)
+			if err != nil {
+				return nil, annotatePath(err, i)
+			}
+			v[i] = dx
+		}
+		return v, nil
+	case json.Number:
+		return fromJSONNumber(v, opts)
+	}
+	return value, nil
+}
+
+func fromJSONNumber(n json.Number, opts JSONOptions) (any, error) {
+	if !opts.PreserveNumbers || strings.ContainsAny(n.String(), ".eE") {
+		return n.Float64()
+	}
+	i, err := strconv.ParseInt(n.String(), 10, 0)
+	if err != nil {
+		return nil, err
+	}
+	return int(i), nil
+}
+
+func fromTypeAnnotation(typeName, value any) (any, error) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s of type annotation is not a string", jsonKeyValue)
+	}
+	switch typeName {
+	case jsonTypeCoordinate:
+		return Coordinate(s), nil
+	case jsonTypeCoordinatePair:
+		return CoordinatePair(s), nil
+	case jsonTypeCoordinateList:
+		return CoordinateList(s), nil
+	}
+	return nil, fmt.Errorf("unknown %s: %v", jsonKeyType, typeName)
+}
@@ -0,0 +1,303 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ggdict_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fzipp/gg/ggdict"
+)
+
+func TestToJSON(t *testing.T) {
+	dict := map[string]any{
+		"name":  "door",
+		"zsort": 3,
+		"fps":   2.0,
+		"pos":   ggdict.Coordinate("{1,2}"),
+		"a":     []any{nil, "<x>"},
+	}
+	tests := []struct {
+		opts ggdict.JSONOptions
+		want string
+	}{
+		{
+			ggdict.JSONOptions{},
+			`{"a":[null,"<x>"],"fps":2,"name":"door","pos":"{1,2}","zsort":3}` + "\n",
+		},
+		{
+			ggdict.JSONOptions{PreserveNumbers: true, AnnotateTypes: true},
+			`{"a":[null,"<x>"],"fps":2.0,"name":"door","pos":{"$type":"coordinate","$value":"{1,2}"},"zsort":3}` + "\n",
+		},
+		{
+			ggdict.JSONOptions{Indent: "  "},
+			`{
+  "a": [
+    null,
+    "<x>"
+  ],
+  "fps": 2,
+  "name": "door",
+  "pos": "{1,2}",
+  "zsort": 3
+}
+`,
+		},
+	}
+	data, err := ggdict.Marshal(dict, ggdict.FormatMonkey)
+	if err != nil {
+		t.Errorf("marshalling %#v returned an error: %s", dict, err)
+		return
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		err := ggdict.ToJSON(&buf, data, ggdict.FormatMonkey, tt.opts)
+		if err != nil {
+			t.Errorf("converting to JSON with %#v returned an error: %s", tt.opts, err)
+			continue
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("converting to JSON with %#v resulted in\n%s, want:\n%s", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestFromJSON(t *testing.T) {
+	json := `{"zsort": 3, "fps": 2.0, "big": 1e3, "pos": {"$type": "coordinate", "$value": "{1,2}"}}`
+	tests := []struct {
+		opts ggdict.JSONOptions
+		want map[string]any
+	}{
+		{
+			ggdict.JSONOptions{AnnotateTypes: true},
+			map[string]any{"zsort": 3.0, "fps": 2.0, "big": 1000.0, "pos": ggdict.Coordinate("{1,2}")},
+		},
+		{
+			ggdict.JSONOptions{PreserveNumbers: true, AnnotateTypes: true},
+			map[string]any{"zsort": 3, "fps": 2.0, "big": 1000.0, "pos": ggdict.Coordinate("{1,2}")},
+		},
+		{
+			ggdict.JSONOptions{PreserveNumbers: true},
+			map[string]any{"zsort": 3, "fps": 2.0, "big": 1000.0, "pos": map[string]any{"$type": "coordinate", "$value": "{1,2}"}},
+		},
+	}
+	for _, tt := range tests {
+		data, err := ggdict.FromJSON(strings.NewReader(json), ggdict.FormatMonkey, tt.opts)
+		if err != nil {
+			t.Errorf("converting from JSON with %#v returned an error: %s", tt.opts, err)
+			continue
+		}
+		dict, err := ggdict.Unmarshal(data, ggdict.FormatMonkey)
+		if err != nil {
+			t.Errorf("unmarshalling data converted from JSON with %#v returned an error: %s", tt.opts, err)
+			continue
+		}
+		if !reflect.DeepEqual(dict, tt.want) {
+			t.Errorf("converting from JSON with %#v resulted in %#v, want: %#v", tt.opts, dict, tt.want)
+		}
+	}
+}
+
+func TestJSONOriginalKeyOrder(t *testing.T) {
+	json := `{"name":"door","objects":[{"zsort":3,"name":"a"}],"b":{"y":1,"x":2}}` + "\n"
+	opts := ggdict.JSONOptions{PreserveNumbers: true, OriginalKeyOrder: true}
+	data, err := ggdict.FromJSON(strings.NewReader(json), ggdict.FormatThimbleweed, opts)
+	if err != nil {
+		t.Errorf("converting from JSON returned an error: %s", err)
+		return
+	}
+	var buf bytes.Buffer
+	err = ggdict.ToJSON(&buf, data, ggdict.FormatThimbleweed, opts)
+	if err != nil {
+		t.Errorf("converting to JSON returned an error: %s", err)
+		return
+	}
+	if got := buf.String(); got != json {
+		t.Errorf("JSON round trip with original key order resulted in %s, want: %s", got, json)
+	}
+}
+
+func TestFromJSONErrors(t *testing.T) {
+	tests := []struct {
+		json      string
+		wantError string
+	}{
+		{`{"a": [{"$type": "point", "$value": "{1,2}"}]}`, "a[0]: unknown $type: point"},
+		{`{"a": {"$type": "coordinate", "$value": 1}}`, "a: $value of type annotation is not a string"},
+		{`{"$type": "coordinate", "$value": "{1,2}"}`, "JSON root is not an object"},
+	}
+	for _, tt := range tests {
+		opts := ggdict.JSONOptions{AnnotateTypes: true}
+		_, err := ggdict.FromJSON(strings.NewReader(tt.json), ggdict.FormatMonkey, opts)
+		if err == nil {
+			t.Errorf("expected error for converting %s from JSON, but no error returned", tt.json)
+			continue
+		}
+		if err.Error() != tt.wantError {
+			t.Errorf("error message for converting %s from JSON was: %q, want: %q", tt.json, err.Error(), tt.wantError)
+		}
+	}
+}
+
+func TestFromJSONNotAnObject(t *testing.T) {
+	tests := []struct {
+		json string
+		opts ggdict.JSONOptions
+	}{
+		{`[1]`, ggdict.JSONOptions{}},
+		{`null`, ggdict.JSONOptions{}},
+		{`null`, ggdict.JSONOptions{OriginalKeyOrder: true}},
+		{`"text"`, ggdict.JSONOptions{}},
+		{`{"$type": "coordinate", "$value": "{1,2}"}`, ggdict.JSONOptions{AnnotateTypes: true}},
+		{`{"$type": "coordinate", "$value": "{1,2}"}`, ggdict.JSONOptions{AnnotateTypes: true, OriginalKeyOrder: true}},
+	}
+	for _, tt := range tests {
+		_, err := ggdict.FromJSON(strings.NewReader(tt.json), ggdict.FormatMonkey, tt.opts)
+		if err == nil {
+			t.Errorf("expected error for converting %s from JSON with %#v, but no error returned", tt.json, tt.opts)
+		}
+		_, err = ggdict.UnmarshalJSON([]byte(tt.json), tt.opts)
+		if err == nil {
+			t.Errorf("expected error for unmarshalling %s from JSON with %#v, but no error returned", tt.json, tt.opts)
+		}
+	}
+}
+
+func TestUnmarshalJSONTypeKeyWithoutAnnotations(t *testing.T) {
+	json := `{"a": {"$type": "coordinate", "$value": "{1,2}"}, "$type": "x", "$value": 1}`
+	want := map[string]any{
+		"a":      map[string]any{"$type": "coordinate", "$value": "{1,2}"},
+		"$type":  "x",
+		"$value": 1,
+	}
+	got, err := ggdict.UnmarshalJSON([]byte(json), ggdict.JSONOptions{PreserveNumbers: true})
+	if err != nil {
+		t.Errorf("unmarshalling JSON %s returned an error: %s", json, err)
+		return
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unmarshalling JSON %s resulted in %#v, want: %#v", json, got, want)
+	}
+}
+
+func TestJSONOriginalKeyOrderEscaping(t *testing.T) {
+	dict := map[string]any{"<a>": "<x> & y"}
+	data, err := ggdict.Marshal(dict, ggdict.FormatThimbleweed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var unordered, ordered bytes.Buffer
+	err = ggdict.ToJSON(&unordered, data, ggdict.FormatThimbleweed, ggdict.JSONOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ggdict.ToJSON(&ordered, data, ggdict.FormatThimbleweed, ggdict.JSONOptions{OriginalKeyOrder: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ordered.String() != unordered.String() {
+		t.Errorf("JSON with original key order was %s, want: %s", ordered.String(), unordered.String())
+	}
+	if want := `{"<a>":"<x> & y"}` + "\n"; unordered.String() != want {
+		t.Errorf("JSON was %s, want: %s", unordered.String(), want)
+	}
+}
+
+func TestMarshalJSONRoundTrip(t *testing.T) {
+	dict := map[string]any{
+		"int":   1,
+		"float": 1.0,
+		"pair":  ggdict.CoordinatePair("{{1,2},{3,4}}"),
+		"list":  []any{ggdict.CoordinateList("{1,2};{3,4}")},
+	}
+	opts := ggdict.JSONOptions{Indent: "\t", AnnotateTypes: true, PreserveNumbers: true}
+	data, err := ggdict.MarshalJSON(dict, opts)
+	if err != nil {
+		t.Errorf("marshalling %#v to JSON returned an error: %s", dict, err)
+		return
+	}
+	got, err := ggdict.UnmarshalJSON(data, opts)
+	if err != nil {
+		t.Errorf("unmarshalling JSON %s returned an error: %s", data, err)
+		return
+	}
+	if !reflect.DeepEqual(got, dict) {
+		t.Errorf("JSON round trip resulted in %#v, want: %#v", got, dict)
+	}
+}
+
+func TestJSONRoundTripValueTypes(t *testing.T) {
+	values := []struct {
+		name string
+		v    any
+		// untyped is the value after a round trip without type
+		// annotations, if it differs from v.
+		untyped any
+	}{
+		{name: "null", v: nil},
+		{name: "string", v: "<door> & \"key\""},
+		{name: "empty string", v: ""},
+		{name: "int", v: 42},
+		{name: "negative int", v: -7},
+		{name: "zero", v: 0},
+		{name: "float", v: 0.25},
+		{name: "integral float", v: 2.0},
+		{name: "negative float", v: -1.5},
+		{name: "large float", v: 1e21},
+		{name: "small float", v: 1e-7},
+		{name: "coordinate", v: ggdict.Coordinate("{213,118}"), untyped: "{213,118}"},
+		{name: "coordinate pair", v: ggdict.CoordinatePair("{{-23,-20},{17,20}}"), untyped: "{{-23,-20},{17,20}}"},
+		{name: "coordinate list", v: ggdict.CoordinateList("{82,94};{134,94}"), untyped: "{82,94};{134,94}"},
+		{name: "empty dictionary", v: map[string]any{}},
+		{name: "empty array", v: []any{}},
+		{name: "array", v: []any{1, 1.0, "1", nil, []any{2}}},
+		{name: "dictionary", v: map[string]any{"a": 1, "b": map[string]any{"c": 0.5}}},
+		{
+			name:    "nested coordinates",
+			v:       []any{map[string]any{"pos": ggdict.Coordinate("{1,2}")}},
+			untyped: []any{map[string]any{"pos": "{1,2}"}},
+		},
+	}
+	for _, annotate := range []bool{false, true} {
+		opts := ggdict.JSONOptions{PreserveNumbers: true, AnnotateTypes: annotate}
+		for _, tt := range values {
+			dict := map[string]any{"value": tt.v}
+			want := dict
+			if !annotate && tt.untyped != nil {
+				want = map[string]any{"value": tt.untyped}
+			}
+			for _, keepOrder := range []bool{false, true} {
+				opts.OriginalKeyOrder = keepOrder
+				data, err := ggdict.Marshal(dict, ggdict.FormatMonkey)
+				if err != nil {
+					t.Fatalf("%s: marshalling returned an error: %s", tt.name, err)
+				}
+				var buf bytes.Buffer
+				err = ggdict.ToJSON(&buf, data, ggdict.FormatMonkey, opts)
+				if err != nil {
+					t.Errorf("%s: converting to JSON with %#v returned an error: %s", tt.name, opts, err)
+					continue
+				}
+				jsonData := buf.String()
+				data, err = ggdict.FromJSON(&buf, ggdict.FormatMonkey, opts)
+				if err != nil {
+					t.Errorf("%s: converting %s from JSON with %#v returned an error: %s", tt.name, jsonData, opts, err)
+					continue
+				}
+				got, err := ggdict.Unmarshal(data, ggdict.FormatMonkey)
+				if err != nil {
+					t.Errorf("%s: unmarshalling returned an error: %s", tt.name, err)
+					continue
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("%s: round trip via JSON %s with %#v resulted in %#v, want: %#v",
+						tt.name, jsonData, opts, got, want)
+				}
+			}
+		}
+	}
+}
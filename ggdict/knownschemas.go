@@ -0,0 +1,166 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ggdict
+
+// knownSchemas construct the schemas for the known kinds of GGDictionary
+// documents by name:
+//
+//	wimpy      Room (*.wimpy) files
+//	animation  Animation (*Animation.json) files
+//	directory  The directory of a ggpack file
+//	savegame   Decrypted savegames
+//
+// The schemas accept both the string representation of coordinates used by
+// Thimbleweed Park and the coordinate types used by Return to Monkey Island.
+var knownSchemas = map[string]func() *Schema{
+	"wimpy":     wimpySchema,
+	"animation": animationFileSchema,
+	"directory": directorySchema,
+	"savegame":  savegameSchema,
+}
+
+// SchemaByName returns the known schema with the given name.
+// Each call returns a newly constructed schema.
+func SchemaByName(name string) (*Schema, bool) {
+	newSchema, ok := knownSchemas[name]
+	if !ok {
+		return nil, false
+	}
+	return newSchema(), true
+}
+
+var (
+	anySchema        = &Schema{Kind: KindAny}
+	stringSchema     = &Schema{Kind: KindString}
+	intSchema        = &Schema{Kind: KindInt}
+	numberSchema     = &Schema{Kind: KindNumber}
+	anyDictSchema    = &Schema{Kind: KindDictionary}
+	stringListSchema = arrayOf(stringSchema)
+
+	// Coordinates are strings in Thimbleweed Park.
+	coordinateSchema     = oneOf(stringSchema, &Schema{Kind: KindCoordinate})
+	coordinatePairSchema = oneOf(stringSchema, &Schema{Kind: KindCoordinatePair})
+	coordinateListSchema = oneOf(stringSchema, &Schema{Kind: KindCoordinateList})
+)
+
+func wimpySchema() *Schema {
+	stringOrStrings := oneOf(stringSchema, stringListSchema)
+	layer := dictionary(map[string]Field{
+		"name":     required(stringOrStrings),
+		"parallax": optional(oneOf(numberSchema, coordinateSchema)),
+		"zsort":    required(intSchema),
+	})
+	object := dictionary(map[string]Field{
+		"name":       required(stringSchema),
+		"parent":     optional(stringSchema),
+		"animations": optional(arrayOf(animationSchema())),
+		"hotspot":    required(coordinatePairSchema),
+		"pos":        required(coordinateSchema),
+		"usedir":     required(stringSchema),
+		"usepos":     required(coordinateSchema),
+		"zsort":      required(intSchema),
+		"prop":       optional(intSchema),
+		"spot":       optional(intSchema),
+		"trigger":    optional(intSchema),
+	})
+	scaling := oneOf(
+		stringSchema,
+		dictionary(map[string]Field{
+			"scaling": required(stringListSchema),
+			"trigger": optional(stringSchema),
+		}),
+	)
+	walkbox := dictionary(map[string]Field{
+		"name":    optional(stringSchema),
+		"polygon": required(coordinateListSchema),
+	})
+	return dictionary(map[string]Field{
+		"name":       required(stringSchema),
+		"sheet":      required(stringSchema),
+		"background": optional(stringOrStrings),
+		"fullscreen": optional(intSchema),
+		"height":     optional(intSchema),
+		"layers":     optional(arrayOf(layer)),
+		"objects":    optional(arrayOf(object)),
+		"roomsize":   required(coordinateSchema),
+		"scaling":    optional(arrayOf(scaling)),
+		"walkboxes":  optional(arrayOf(walkbox)),
+	})
+}
+
+// animationSchema returns the schema of an animation, which may contain
+// layers that are animations themselves.
+func animationSchema() *Schema {
+	anim := dictionary(map[string]Field{
+		"name":     required(stringSchema),
+		"fps":      optional(numberSchema),
+		"triggers": optional(arrayOf(oneOf(stringSchema, &Schema{Kind: KindNull}))),
+		"frames":   optional(stringListSchema),
+		"offsets":  optional(arrayOf(coordinateSchema)),
+		"loop":     optional(intSchema),
+		"flags":    optional(intSchema),
+	})
+	anim.Fields["layers"] = optional(arrayOf(anim))
+	return anim
+}
+
+func animationFileSchema() *Schema {
+	return dictionary(map[string]Field{
+		"animations": required(arrayOf(animationSchema())),
+		"sheet":      optional(stringSchema),
+	})
+}
+
+func directorySchema() *Schema {
+	file := dictionary(map[string]Field{
+		"filename": required(stringSchema),
+		"offset":   required(intSchema),
+		"size":     required(intSchema),
+	})
+	return dictionary(map[string]Field{
+		"files": required(arrayOf(file)),
+	})
+}
+
+func savegameSchema() *Schema {
+	return dictionary(map[string]Field{
+		"actors":        optional(anyDictSchema),
+		"callbacks":     optional(anyDictSchema),
+		"currentRoom":   optional(stringSchema),
+		"dialog":        optional(anyDictSchema),
+		"easy_mode":     optional(intSchema),
+		"gameGUID":      optional(stringSchema),
+		"gameTime":      required(numberSchema),
+		"globals":       optional(anyDictSchema),
+		"inputState":    optional(intSchema),
+		"inventory":     optional(anySchema),
+		"objects":       optional(anyDictSchema),
+		"rooms":         optional(anyDictSchema),
+		"savebuild":     required(intSchema),
+		"savetime":      required(intSchema),
+		"selectedActor": optional(stringSchema),
+		"version":       required(intSchema),
+	})
+}
+
+func dictionary(fields map[string]Field) *Schema {
+	return &Schema{Kind: KindDictionary, Fields: fields}
+}
+
+func arrayOf(elem *Schema) *Schema {
+	return &Schema{Kind: KindArray, Elem: elem}
+}
+
+func oneOf(alternatives ...*Schema) *Schema {
+	return &Schema{OneOf: alternatives}
+}
+
+func required(s *Schema) Field {
+	return Field{Schema: s, Required: true}
+}
+
+func optional(s *Schema) Field {
+	return Field{Schema: s}
+}
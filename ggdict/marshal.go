@@ -5,19 +5,79 @@
 package ggdict
 
 import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
 	"sort"
 	"strconv"
+	"strings"
 )
 
-func Marshal(dict map[string]any, f Format) []byte {
+// maxShortStringIndex is the highest string index that can be encoded
+// in a format with ShortStringIndices.
+const maxShortStringIndex = 0xFFFF
+
+// Marshal encodes a dictionary in the GGDictionary format with the default
+// options.
+//
+// Besides the values of decoded dictionaries, Marshal accepts bools, which
+// are encoded as integers 1 and 0, json.Number values, values implementing
+// encoding.TextMarshaler, which are encoded as strings, pointers, and
+// slices, arrays and maps with string keys of any supported type. For other
+// types it returns a *MarshalError with the path of the value.
+// It also returns an error if the format uses short (16-bit) string indices
+// and the dictionary contains too many distinct strings.
+func Marshal(dict map[string]any, f Format) ([]byte, error) {
+	return MarshalOptions{}.Marshal(dict, f)
+}
+
+// MarshalOptions are options for encoding a dictionary in the
+// GGDictionary format.
+type MarshalOptions struct {
+	// FrequencySortedStrings orders the string table by the number of
+	// references to each string, most frequently used first, instead of by
+	// first occurrence. With short string indices this keeps the most
+	// frequently used strings within the 16-bit index range.
+	FrequencySortedStrings bool
+}
+
+// Marshal encodes a dictionary in the GGDictionary format with the options.
+func (o MarshalOptions) Marshal(dict map[string]any, f Format) ([]byte, error) {
+	return o.marshal(nil, dict, f)
+}
+
+// AppendMarshal appends the encoding of a dictionary in the GGDictionary
+// format with the options to dst and returns the extended buffer. Callers
+// that encode many dictionaries can reuse a buffer this way.
+func (o MarshalOptions) AppendMarshal(dst []byte, dict map[string]any, f Format) ([]byte, error) {
+	return o.marshal(dst, dict, f)
+}
+
+// marshal appends the encoding of a root dictionary, which is either a
+// map[string]any or an *orderedDict, to dst.
+func (o MarshalOptions) marshal(dst []byte, dict any, f Format) ([]byte, error) {
 	m := newMarshaller(f)
+	m.buf, m.base = dst, len(dst)
+	if o.FrequencySortedStrings {
+		// The counting pass uses long string indices, so that it
+		// does not fail before the strings are sorted.
+		counter := newMarshaller(Format{})
+		counter.stringCounts = make(map[string]int)
+		if err := counter.writeValue(dict); err != nil {
+			return nil, err
+		}
+		m.presetStrings(counter.stringsByFrequency())
+	}
 	m.writeRawUint32(formatSignature)
 	m.writeRawUint32(1)
 	m.writeRawUint32(0)
-	m.writeValue(dict)
+	if err := m.writeValue(dict); err != nil {
+		return nil, err
+	}
 	m.writeStringOffsets()
 	m.writeStrings()
-	return m.buf
+	return m.buf, nil
 }
 
 type marshaller struct {
@@ -25,7 +85,11 @@ type marshaller struct {
 	offset        int
 	strings       []string
 	stringIndices map[string]int
-	format        Format
+	// stringCounts counts the references to each string if it is not nil.
+	stringCounts map[string]int
+	format       Format
+	// base is the start of the encoded dictionary within buf.
+	base int
 }
 
 func newMarshaller(f Format) *marshaller {
@@ -35,31 +99,110 @@ func newMarshaller(f Format) *marshaller {
 	}
 }
 
-func (m *marshaller) writeValue(value any) {
+// stringsByFrequency returns the strings written so far, ordered by their
+// number of references, most frequently used first. Strings with the same
+// number of references keep the order of their first occurrence.
+func (m *marshaller) stringsByFrequency() []string {
+	strs := make([]string, len(m.strings))
+	copy(strs, m.strings)
+	sort.SliceStable(strs, func(i, j int) bool {
+		return m.stringCounts[strs[i]] > m.stringCounts[strs[j]]
+	})
+	return strs
+}
+
+// presetStrings assigns the string indices in the order of the given strings.
+func (m *marshaller) presetStrings(strs []string) {
+	for i, s := range strs {
+		m.stringIndices[s] = i
+	}
+	m.strings = strs
+}
+
+func (m *marshaller) writeValue(value any) error {
 	switch v := value.(type) {
 	case nil:
 		m.writeNull()
 	case map[string]any:
-		m.writeDictionary(v)
+		return m.writeDictionary(v)
+	case *orderedDict:
+		return m.writeOrderedDictionary(v)
 	case []any:
-		m.writeArray(v)
+		return m.writeArray(v)
 	case string:
-		m.writeString(v)
+		return m.writeString(v)
 	case int:
-		m.writeInteger(v)
+		return m.writeInteger(v)
 	case int32:
-		m.writeInteger(int(v))
+		return m.writeInteger(int(v))
 	case int64:
-		m.writeInteger(int(v))
+		return m.writeInteger(int(v))
 	case uint32:
-		m.writeInteger(int(v))
+		return m.writeInteger(int(v))
 	case uint64:
-		m.writeInteger(int(v))
+		return m.writeInteger(int(v))
 	case float64:
-		m.writeFloat(v)
+		return m.writeFloat(v)
 	case float32:
-		m.writeFloat(float64(v))
+		return m.writeFloat(float64(v))
+	case bool:
+		return m.writeBool(v)
+	case Coordinate:
+		return m.writeCoordinate(typeCoordinate, string(v))
+	case CoordinatePair:
+		return m.writeCoordinate(typeCoordinatePair, string(v))
+	case CoordinateList:
+		return m.writeCoordinate(typeCoordinateList, string(v))
+	case json.Number:
+		return m.writeNumber(v)
+	case encoding.TextMarshaler:
+		return m.writeTextMarshaler(v)
+	default:
+		return m.writeReflectValue(reflect.ValueOf(value))
+	}
+	return nil
+}
+
+// writeReflectValue writes values of types that are not handled by
+// writeValue directly, like typed slices and maps, pointers and types
+// derived from the basic types.
+func (m *marshaller) writeReflectValue(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			m.writeNull()
+			return nil
+		}
+		return m.writeValue(v.Elem().Interface())
+	case reflect.Slice:
+		if v.IsNil() {
+			m.writeNull()
+			return nil
+		}
+		return m.writeReflectArray(v)
+	case reflect.Array:
+		return m.writeReflectArray(v)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			break
+		}
+		if v.IsNil() {
+			m.writeNull()
+			return nil
+		}
+		return m.writeReflectDictionary(v)
+	case reflect.String:
+		return m.writeString(v.String())
+	case reflect.Bool:
+		return m.writeBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return m.writeInteger(int(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return m.writeInteger(int(v.Uint()))
+	case reflect.Float32, reflect.Float64:
+		return m.writeFloat(v.Float())
 	}
+	return fmt.Errorf("unsupported value type: %s", v.Type())
 }
 
 func (m *marshaller) writeTypeMarker(t valueType) {
@@ -70,15 +213,54 @@ func (m *marshaller) writeNull() {
 	m.writeTypeMarker(typeNull)
 }
 
-func (m *marshaller) writeDictionary(d map[string]any) {
+func (m *marshaller) writeDictionary(d map[string]any) error {
 	m.writeTypeMarker(typeDictionary)
 	m.writeRawUint32(len(d))
 	// sorted keys for reproducible results
 	for _, k := range sortedKeys(d) {
-		m.writeStringIndex(k)
-		m.writeValue(d[k])
+		if err := m.writeStringIndex(k); err != nil {
+			return err
+		}
+		if err := m.writeValue(d[k]); err != nil {
+			return annotatePath(err, k)
+		}
 	}
 	m.writeTypeMarker(typeDictionary)
+	return nil
+}
+
+func (m *marshaller) writeOrderedDictionary(d *orderedDict) error {
+	m.writeTypeMarker(typeDictionary)
+	m.writeRawUint32(len(d.keys))
+	for _, k := range d.keys {
+		if err := m.writeStringIndex(k); err != nil {
+			return err
+		}
+		if err := m.writeValue(d.values[k]); err != nil {
+			return annotatePath(err, k)
+		}
+	}
+	m.writeTypeMarker(typeDictionary)
+	return nil
+}
+
+func (m *marshaller) writeReflectDictionary(d reflect.Value) error {
+	keys := d.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	m.writeTypeMarker(typeDictionary)
+	m.writeRawUint32(len(keys))
+	for _, k := range keys {
+		if err := m.writeStringIndex(k.String()); err != nil {
+			return err
+		}
+		if err := m.writeValue(d.MapIndex(k).Interface()); err != nil {
+			return annotatePath(err, k.String())
+		}
+	}
+	m.writeTypeMarker(typeDictionary)
+	return nil
 }
 
 func sortedKeys(m map[string]any) []string {
@@ -90,42 +272,135 @@ func sortedKeys(m map[string]any) []string {
 	return keys
 }
 
-func (m *marshaller) writeArray(a []any) {
+func (m *marshaller) writeArray(a []any) error {
 	m.writeTypeMarker(typeArray)
 	m.writeRawUint32(len(a))
-	for _, v := range a {
-		m.writeValue(v)
+	for i, v := range a {
+		if err := m.writeValue(v); err != nil {
+			return annotatePath(err, i)
+		}
+	}
+	m.writeTypeMarker(typeArray)
+	return nil
+}
+
+func (m *marshaller) writeReflectArray(a reflect.Value) error {
+	m.writeTypeMarker(typeArray)
+	m.writeRawUint32(a.Len())
+	for i := 0; i < a.Len(); i++ {
+		if err := m.writeValue(a.Index(i).Interface()); err != nil {
+			return annotatePath(err, i)
+		}
 	}
 	m.writeTypeMarker(typeArray)
+	return nil
 }
 
-func (m *marshaller) writeString(s string) {
+func (m *marshaller) writeString(s string) error {
 	m.writeTypeMarker(typeString)
-	m.writeStringIndex(s)
+	return m.writeStringIndex(s)
 }
 
-func (m *marshaller) writeInteger(i int) {
+func (m *marshaller) writeInteger(i int) error {
 	m.writeTypeMarker(typeInteger)
-	m.writeStringIndex(strconv.Itoa(i))
+	return m.writeStringIndex(strconv.Itoa(i))
 }
 
-func (m *marshaller) writeFloat(f float64) {
+func (m *marshaller) writeFloat(f float64) error {
 	m.writeTypeMarker(typeFloat)
-	m.writeStringIndex(strconv.FormatFloat(f, 'g', -1, 64))
+	return m.writeStringIndex(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+// writeBool writes a boolean as integer 1 or 0, since the format has no
+// boolean type.
+func (m *marshaller) writeBool(b bool) error {
+	if b {
+		return m.writeInteger(1)
+	}
+	return m.writeInteger(0)
+}
+
+// writeNumber writes a JSON number as integer if it has neither a decimal
+// point nor an exponent, otherwise as float.
+func (m *marshaller) writeNumber(n json.Number) error {
+	if !strings.ContainsAny(string(n), ".eE") {
+		if i, err := n.Int64(); err == nil {
+			return m.writeInteger(int(i))
+		}
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return fmt.Errorf("invalid number: %q", n)
+	}
+	return m.writeFloat(f)
+}
+
+func (m *marshaller) writeTextMarshaler(tm encoding.TextMarshaler) error {
+	if v := reflect.ValueOf(tm); v.Kind() == reflect.Pointer && v.IsNil() {
+		m.writeNull()
+		return nil
+	}
+	text, err := tm.MarshalText()
+	if err != nil {
+		return err
+	}
+	return m.writeString(string(text))
+}
+
+func (m *marshaller) writeCoordinate(t valueType, s string) error {
+	if !m.format.CoordinateTypes {
+		return m.writeString(s)
+	}
+	m.writeTypeMarker(t)
+	return m.writeStringIndex(s)
 }
 
-func (m *marshaller) writeStringIndex(s string) {
+func (m *marshaller) writeStringIndex(s string) error {
 	idx, ok := m.stringIndices[s]
 	if !ok {
 		idx = len(m.strings)
 		m.stringIndices[s] = idx
 		m.strings = append(m.strings, s)
 	}
+	if m.stringCounts != nil {
+		m.stringCounts[s]++
+	}
 	if m.format.ShortStringIndices {
+		if idx > maxShortStringIndex {
+			return fmt.Errorf("string index %d for %q exceeds the maximum of %d for short string indices", idx, s, maxShortStringIndex)
+		}
 		m.writeRawUint16(idx)
 	} else {
 		m.writeRawUint32(idx)
 	}
+	return nil
+}
+
+// MarshalError is an error for a value that could not be encoded or
+// converted.
+type MarshalError struct {
+	// Path is the path of the value within the dictionary.
+	Path Path
+	Err  error
+}
+
+func (e *MarshalError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Err)
+}
+
+func (e *MarshalError) Unwrap() error {
+	return e.Err
+}
+
+// annotatePath prepends a path element to the path of a MarshalError,
+// creating it if err is not a MarshalError yet.
+func annotatePath(err error, elem any) error {
+	me, ok := err.(*MarshalError)
+	if !ok {
+		return &MarshalError{Path: Path{elem}, Err: err}
+	}
+	me.Path = append(Path{elem}, me.Path...)
+	return me
 }
 
 func (m *marshaller) writeStringOffsets() {
@@ -146,7 +421,7 @@ func (m *marshaller) writeStringOffsets() {
 }
 
 func (m *marshaller) writeStringOffsetsStart(offset int) {
-	byteOrder.PutUint32(m.buf[8:], uint32(offset))
+	byteOrder.PutUint32(m.buf[m.base+8:], uint32(offset))
 }
 
 func (m *marshaller) writeStrings() {
@@ -157,19 +432,21 @@ func (m *marshaller) writeStrings() {
 }
 
 func (m *marshaller) writeRawString(s string) {
-	m.writeRawBytes(append([]byte(s), 0))
+	m.buf = append(m.buf, s...)
+	m.buf = append(m.buf, 0)
+	m.offset += len(s) + 1
 }
 
 func (m *marshaller) writeRawUint32(i int) {
-	b := make([]byte, 4)
-	byteOrder.PutUint32(b, uint32(i))
-	m.writeRawBytes(b)
+	var b [4]byte
+	byteOrder.PutUint32(b[:], uint32(i))
+	m.writeRawBytes(b[:])
 }
 
 func (m *marshaller) writeRawUint16(i int) {
-	b := make([]byte, 2)
-	byteOrder.PutUint16(b, uint16(i))
-	m.writeRawBytes(b)
+	var b [2]byte
+	byteOrder.PutUint16(b[:], uint16(i))
+	m.writeRawBytes(b[:])
 }
 
 func (m *marshaller) writeRawBytes(b []byte) {
@@ -5,7 +5,11 @@
 package ggdict_test
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"reflect"
+	"strconv"
 	"testing"
 
 	"github.com/fzipp/gg/ggdict"
@@ -215,8 +219,190 @@ func TestMarshal(t *testing.T) {
 		}},
 	}
 	for _, tt := range tests {
-		if data := ggdict.Marshal(tt.dict, ggdict.FormatThimbleweed); !reflect.DeepEqual(data, tt.want) {
+		data, err := ggdict.Marshal(tt.dict, ggdict.FormatThimbleweed)
+		if err != nil {
+			t.Errorf("ggdict marshalling of %#v returned an error: %s", tt.dict, err)
+			continue
+		}
+		if !reflect.DeepEqual(data, tt.want) {
 			t.Errorf("ggdict marshalling of %#v was:\n%#v, want:\n%#v", tt.dict, data, tt.want)
 		}
 	}
 }
+
+func TestMarshalFrequencySortedStrings(t *testing.T) {
+	dict := map[string]any{"a": "x", "b": "x", "c": "x"}
+	tests := []struct {
+		options     ggdict.MarshalOptions
+		wantStrings string
+	}{
+		{ggdict.MarshalOptions{}, "\x08a\x00x\x00b\x00c\x00"},
+		{ggdict.MarshalOptions{FrequencySortedStrings: true}, "\x08x\x00a\x00b\x00c\x00"},
+	}
+	for _, tt := range tests {
+		data, err := tt.options.Marshal(dict, ggdict.FormatThimbleweed)
+		if err != nil {
+			t.Errorf("marshalling with %#v returned an error: %s", tt.options, err)
+			continue
+		}
+		if !bytes.HasSuffix(data, []byte(tt.wantStrings)) {
+			t.Errorf("marshalling with %#v resulted in strings section %q, want: %q", tt.options, data[len(data)-len(tt.wantStrings):], tt.wantStrings)
+		}
+		newDict, err := ggdict.Unmarshal(data, ggdict.FormatThimbleweed)
+		if err != nil {
+			t.Errorf("unmarshalling data marshalled with %#v returned an error: %s", tt.options, err)
+			continue
+		}
+		if !reflect.DeepEqual(newDict, dict) {
+			t.Errorf("round trip with %#v resulted in %#v, want: %#v", tt.options, newDict, dict)
+		}
+	}
+}
+
+func TestAppendMarshal(t *testing.T) {
+	dict := map[string]any{"name": "Test", "items": []any{1, 2.5, "three"}}
+	want, err := ggdict.Marshal(dict, ggdict.FormatThimbleweed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		dst []byte
+	}{
+		{nil},
+		{[]byte("prefix")},
+		{make([]byte, 0, 1024)},
+	}
+	for _, tt := range tests {
+		prefix := string(tt.dst)
+		got, err := ggdict.MarshalOptions{}.AppendMarshal(tt.dst, dict, ggdict.FormatThimbleweed)
+		if err != nil {
+			t.Errorf("appending to %q returned an error: %s", prefix, err)
+			continue
+		}
+		if string(got[:len(prefix)]) != prefix || !bytes.Equal(got[len(prefix):], want) {
+			t.Errorf("appending to %q resulted in %q, want: %q", prefix, got, prefix+string(want))
+		}
+	}
+}
+
+func TestMarshalShortStringIndexOverflow(t *testing.T) {
+	dict := make(map[string]any)
+	for i := 0; i <= 0xFFFF; i++ {
+		dict["k"+strconv.Itoa(i)] = nil
+	}
+	if _, err := ggdict.Marshal(dict, ggdict.FormatMonkey); err != nil {
+		t.Errorf("marshalling %d strings with short string indices returned an error: %s", len(dict), err)
+	}
+	dict["k65536"] = nil
+	_, err := ggdict.Marshal(dict, ggdict.FormatMonkey)
+	want := `string index 65536 for "k9999" exceeds the maximum of 65535 for short string indices`
+	if err == nil || err.Error() != want {
+		t.Errorf("error for marshalling %d strings with short string indices was %v, want: %q", len(dict), err, want)
+	}
+	if _, err := ggdict.Marshal(dict, ggdict.FormatThimbleweed); err != nil {
+		t.Errorf("marshalling %d strings with long string indices returned an error: %s", len(dict), err)
+	}
+}
+
+type testTextMarshaler struct{ s string }
+
+func (m *testTextMarshaler) MarshalText() ([]byte, error) {
+	return []byte("text:" + m.s), nil
+}
+
+type testName string
+
+func TestMarshalGoTypes(t *testing.T) {
+	n := 7
+	var nilPtr *int
+	dict := map[string]any{
+		"bool_true":    true,
+		"bool_false":   false,
+		"strings":      []string{"a", "b"},
+		"ints":         [2]int{1, 2},
+		"string_map":   map[string]string{"k": "v"},
+		"nested_map":   map[string][]float64{"f": {0.5}},
+		"named_string": testName("x"),
+		"int8":         int8(-3),
+		"uint16":       uint16(4),
+		"json_int":     json.Number("12"),
+		"json_float":   json.Number("1e3"),
+		"text":         &testTextMarshaler{"y"},
+		"pointer":      &n,
+		"nil_pointer":  nilPtr,
+		"nil_slice":    []string(nil),
+	}
+	data, err := ggdict.Marshal(dict, ggdict.FormatThimbleweed)
+	if err != nil {
+		t.Errorf("marshalling %#v returned an error: %s", dict, err)
+		return
+	}
+	got, err := ggdict.Unmarshal(data, ggdict.FormatThimbleweed)
+	if err != nil {
+		t.Errorf("unmarshalling returned an error: %s", err)
+		return
+	}
+	want := map[string]any{
+		"bool_true":    1,
+		"bool_false":   0,
+		"strings":      []any{"a", "b"},
+		"ints":         []any{1, 2},
+		"string_map":   map[string]any{"k": "v"},
+		"nested_map":   map[string]any{"f": []any{0.5}},
+		"named_string": "x",
+		"int8":         -3,
+		"uint16":       4,
+		"json_int":     12,
+		"json_float":   1000.0,
+		"text":         "text:y",
+		"pointer":      7,
+		"nil_pointer":  nil,
+		"nil_slice":    nil,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("marshalling Go types resulted in\n%#v, want:\n%#v", got, want)
+	}
+}
+
+func TestMarshalUnsupportedTypes(t *testing.T) {
+	tests := []struct {
+		dict      map[string]any
+		wantPath  ggdict.Path
+		wantError string
+	}{
+		{
+			map[string]any{"objects": []any{map[string]any{"name": "door", "action": func() {}}}},
+			ggdict.Path{"objects", 0, "action"},
+			"objects[0].action: unsupported value type: func()",
+		},
+		{
+			map[string]any{"a": map[int]string{1: "x"}},
+			ggdict.Path{"a"},
+			"a: unsupported value type: map[int]string",
+		},
+		{
+			map[string]any{"a": []struct{}{{}}},
+			ggdict.Path{"a", 0},
+			"a[0]: unsupported value type: struct {}",
+		},
+		{
+			map[string]any{"n": json.Number("x")},
+			ggdict.Path{"n"},
+			`n: invalid number: "x"`,
+		},
+	}
+	for _, tt := range tests {
+		_, err := ggdict.Marshal(tt.dict, ggdict.FormatThimbleweed)
+		var me *ggdict.MarshalError
+		if !errors.As(err, &me) {
+			t.Errorf("error for marshalling %#v was %v, want: *MarshalError", tt.dict, err)
+			continue
+		}
+		if !reflect.DeepEqual(me.Path, tt.wantPath) {
+			t.Errorf("error path for marshalling %#v was %#v, want: %#v", tt.dict, me.Path, tt.wantPath)
+		}
+		if err.Error() != tt.wantError {
+			t.Errorf("error message for marshalling %#v was %q, want: %q", tt.dict, err.Error(), tt.wantError)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ggdict
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Conflict is a path where both sides of a three-way merge changed
+// the value of a common base in different ways. Ours and Theirs are the
+// changes relative to the base.
+type Conflict struct {
+	Path   Path
+	Ours   Change
+	Theirs Change
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s: ours %s, theirs %s", c.Path, describeChange(c.Ours), describeChange(c.Theirs))
+}
+
+func describeChange(c Change) string {
+	switch c.Kind {
+	case Added:
+		return "added " + formatValue(c.New)
+	case Removed:
+		return "removed " + formatValue(c.Old)
+	}
+	return fmt.Sprintf("modified %s -> %s", formatValue(c.Old), formatValue(c.New))
+}
+
+// Merge performs a three-way merge of two dictionaries, ours and theirs,
+// that were both derived from a common base dictionary. Changes made on
+// only one side are taken over, identical changes made on both sides are
+// taken over once.
+//
+// Nested dictionaries are merged key by key. Arrays are merged index by
+// index if neither side changed their length, otherwise an array that was
+// changed on both sides is a conflict. For each conflict the merged
+// dictionary contains our value.
+//
+// The merged dictionary is a new dictionary, the given dictionaries are not
+// modified.
+func Merge(base, ours, theirs map[string]any) (map[string]any, []Conflict) {
+	m := &merger{}
+	merged := m.mergeDictionaries(Path{}, base, ours, theirs)
+	return copyValue(merged).(map[string]any), m.conflicts
+}
+
+// missing is the value of a key that is not present in a dictionary.
+var missing any = missingValue{}
+
+type missingValue struct{}
+
+type merger struct {
+	conflicts []Conflict
+}
+
+func (m *merger) mergeValues(p Path, base, ours, theirs any) any {
+	switch {
+	case reflect.DeepEqual(ours, theirs):
+		return ours
+	case reflect.DeepEqual(base, ours):
+		return theirs
+	case reflect.DeepEqual(base, theirs):
+		return ours
+	}
+	switch o := ours.(type) {
+	case map[string]any:
+		t, ok := theirs.(map[string]any)
+		if !ok {
+			break
+		}
+		if base == missing {
+			return m.mergeDictionaries(p, nil, o, t)
+		}
+		if b, ok := base.(map[string]any); ok {
+			return m.mergeDictionaries(p, b, o, t)
+		}
+	case []any:
+		t, ok := theirs.([]any)
+		if !ok {
+			break
+		}
+		if b, ok := base.([]any); ok && len(b) == len(o) && len(b) == len(t) {
+			return m.mergeArrays(p, b, o, t)
+		}
+	}
+	m.conflicts = append(m.conflicts, Conflict{
+		Path:   p,
+		Ours:   change(p, base, ours),
+		Theirs: change(p, base, theirs),
+	})
+	return ours
+}
+
+func (m *merger) mergeDictionaries(p Path, base, ours, theirs map[string]any) map[string]any {
+	merged := make(map[string]any)
+	for _, k := range unionKeys(base, ours, theirs) {
+		v := m.mergeValues(appendPath(p, k), lookup(base, k), lookup(ours, k), lookup(theirs, k))
+		if v != missing {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+func (m *merger) mergeArrays(p Path, base, ours, theirs []any) []any {
+	merged := make([]any, len(base))
+	for i := range base {
+		merged[i] = m.mergeValues(appendPath(p, i), base[i], ours[i], theirs[i])
+	}
+	return merged
+}
+
+// lookup returns the value for key k in dictionary d,
+// or missing if d does not contain the key.
+func lookup(d map[string]any, k string) any {
+	v, ok := d[k]
+	if !ok {
+		return missing
+	}
+	return v
+}
+
+// change returns the change from a base value to a new value,
+// where either of the values may be missing.
+func change(p Path, base, v any) Change {
+	switch {
+	case base == missing:
+		return Change{Kind: Added, Path: p, New: v}
+	case v == missing:
+		return Change{Kind: Removed, Path: p, Old: base}
+	}
+	return Change{Kind: Modified, Path: p, Old: base, New: v}
+}
@@ -0,0 +1,109 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ggdict_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fzipp/gg/ggdict"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name          string
+		base          map[string]any
+		ours          map[string]any
+		theirs        map[string]any
+		want          map[string]any
+		wantConflicts []string
+	}{
+		{
+			"unchanged",
+			testDict(), testDict(), testDict(),
+			testDict(),
+			nil,
+		},
+		{
+			"changes on different keys",
+			testDict(),
+			map[string]any{
+				"name": "Room",
+				"objects": []any{
+					map[string]any{"name": "door", "zsort": 8},
+					map[string]any{"name": "rope", "zsort": 4},
+				},
+			},
+			map[string]any{
+				"name": "Room",
+				"objects": []any{
+					map[string]any{"name": "door", "zsort": 3},
+					map[string]any{"name": "rope", "zsort": 4, "pos": ggdict.Coordinate("{1,2}")},
+				},
+				"sheet": "RoomSheet",
+			},
+			map[string]any{
+				"name": "Room",
+				"objects": []any{
+					map[string]any{"name": "door", "zsort": 8},
+					map[string]any{"name": "rope", "zsort": 4, "pos": ggdict.Coordinate("{1,2}")},
+				},
+				"sheet": "RoomSheet",
+			},
+			nil,
+		},
+		{
+			"identical changes",
+			map[string]any{"a": 1, "b": 2},
+			map[string]any{"a": 5},
+			map[string]any{"a": 5},
+			map[string]any{"a": 5},
+			nil,
+		},
+		{
+			"added on both sides",
+			map[string]any{},
+			map[string]any{"d": map[string]any{"x": 1}},
+			map[string]any{"d": map[string]any{"y": 2}},
+			map[string]any{"d": map[string]any{"x": 1, "y": 2}},
+			nil,
+		},
+		{
+			"conflicts",
+			map[string]any{"a": 1, "b": 2, "c": []any{1, 2}},
+			map[string]any{"a": 3, "c": []any{1, 2, 3}},
+			map[string]any{"a": 4, "b": 5, "c": []any{1}, "d": "x"},
+			map[string]any{"a": 3, "c": []any{1, 2, 3}, "d": "x"},
+			[]string{
+				"a: ours modified 1 -> 3, theirs modified 1 -> 4",
+				"b: ours removed 2, theirs modified 2 -> 5",
+				"c: ours modified [1, 2] -> [1, 2, 3], theirs modified [1, 2] -> [1]",
+			},
+		},
+	}
+	for _, tt := range tests {
+		merged, conflicts := ggdict.Merge(tt.base, tt.ours, tt.theirs)
+		if !reflect.DeepEqual(merged, tt.want) {
+			t.Errorf("%s: merge resulted in %#v, want: %#v", tt.name, merged, tt.want)
+		}
+		var got []string
+		for _, c := range conflicts {
+			got = append(got, c.String())
+		}
+		if !reflect.DeepEqual(got, tt.wantConflicts) {
+			t.Errorf("%s: merge conflicts were %q, want: %q", tt.name, got, tt.wantConflicts)
+		}
+	}
+}
+
+func TestMergeDoesNotModifyInput(t *testing.T) {
+	base, ours, theirs := testDict(), testDict(), testDict()
+	theirs["objects"].([]any)[0].(map[string]any)["zsort"] = 9
+	merged, _ := ggdict.Merge(base, ours, theirs)
+	merged["objects"].([]any)[1].(map[string]any)["zsort"] = 10
+	if !reflect.DeepEqual(base, testDict()) || !reflect.DeepEqual(ours, testDict()) {
+		t.Errorf("merge modified its input dictionaries")
+	}
+}
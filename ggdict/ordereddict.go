@@ -0,0 +1,56 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ggdict
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// orderedDict is a dictionary that keeps the order of its keys. It is used
+// internally to convert between the GGDictionary format and JSON without
+// sorting the keys.
+type orderedDict struct {
+	keys   []string
+	values map[string]any
+}
+
+func newOrderedDict() *orderedDict {
+	return &orderedDict{values: make(map[string]any)}
+}
+
+func (d *orderedDict) set(k string, v any) {
+	if _, ok := d.values[k]; !ok {
+		d.keys = append(d.keys, k)
+	}
+	d.values[k] = v
+}
+
+// MarshalJSON implements the json.Marshaler interface. It writes the keys
+// in their order. Like the output of ToJSON, the keys and values are
+// written without escaping HTML characters.
+func (d *orderedDict) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	buf.WriteByte('{')
+	for i, k := range d.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := enc.Encode(k); err != nil {
+			return nil, err
+		}
+		// Replace the newline written by Encode.
+		buf.Truncate(buf.Len() - 1)
+		buf.WriteByte(':')
+		if err := enc.Encode(d.values[k]); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
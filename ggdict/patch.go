@@ -0,0 +1,206 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ggdict
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Patch is a JSON Patch document as specified by RFC 6902.
+type Patch []PatchOperation
+
+// PatchOperation is an operation of a JSON Patch document. The supported
+// operations are "add", "remove", "replace", "move", "copy" and "test".
+// Path and From are JSON Pointers as specified by RFC 6901,
+// e.g. "/objects/3/hotspot".
+type PatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. The value of the
+// operation is decoded like by UnmarshalJSON with PreserveNumbers, so that
+// integers and floats keep their types, and type annotation objects are
+// decoded as coordinate values.
+func (op *PatchOperation) UnmarshalJSON(data []byte) error {
+	type plainOperation PatchOperation
+	var raw struct {
+		plainOperation
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*op = PatchOperation(raw.plainOperation)
+	if len(raw.Value) == 0 {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw.Value))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	v, err := fromJSONValue(v, JSONOptions{PreserveNumbers: true, AnnotateTypes: true})
+	if err != nil {
+		return fmt.Errorf("value of operation %s %s: %w", op.Op, op.Path, err)
+	}
+	op.Value = v
+	return nil
+}
+
+// Apply applies the patch to a copy of the dictionary and returns the
+// patched copy. If an operation fails, no copy is returned and the
+// dictionary is left unchanged.
+func (p Patch) Apply(dict map[string]any) (map[string]any, error) {
+	root := copyValue(dict).(map[string]any)
+	for i, op := range p {
+		var err error
+		root, err = op.apply(root)
+		if err != nil {
+			return nil, fmt.Errorf("patch operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return root, nil
+}
+
+func (op PatchOperation) apply(root map[string]any) (map[string]any, error) {
+	path, err := ParsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch op.Op {
+	case "add":
+		return add(root, path, copyValue(op.Value))
+	case "remove":
+		if len(path) == 0 {
+			return nil, errors.New("cannot remove root")
+		}
+		return root, Delete(root, path)
+	case "replace":
+		if len(path) == 0 {
+			return replaceRoot(op.Value)
+		}
+		if _, err := Get(root, path); err != nil {
+			return nil, err
+		}
+		return root, Set(root, path, copyValue(op.Value))
+	case "move", "copy":
+		from, err := ParsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		v, err := Get(root, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "copy" {
+			return add(root, path, copyValue(v))
+		}
+		if isPathPrefix(from, path) && len(from) < len(path) {
+			return nil, errors.New("cannot move a value into one of its children")
+		}
+		if len(from) == 0 {
+			return nil, errors.New("cannot move root")
+		}
+		if err := Delete(root, from); err != nil {
+			return nil, err
+		}
+		return add(root, path, v)
+	case "test":
+		v, err := Get(root, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(v, op.Value) {
+			return nil, fmt.Errorf("test failed: value is %s, want: %s", formatValue(v), formatValue(op.Value))
+		}
+		return root, nil
+	}
+	return nil, fmt.Errorf("unknown operation: %q", op.Op)
+}
+
+func add(root map[string]any, p Path, value any) (map[string]any, error) {
+	if len(p) == 0 {
+		return replaceRoot(value)
+	}
+	return root, insert(root, p, value)
+}
+
+func replaceRoot(value any) (map[string]any, error) {
+	dict, ok := value.(map[string]any)
+	if !ok {
+		return nil, errors.New("root is not a dictionary")
+	}
+	return copyValue(dict).(map[string]any), nil
+}
+
+// ParsePointer parses a JSON Pointer as specified by RFC 6901,
+// e.g. "/objects/3/hotspot". All elements of the returned path are
+// strings, which are interpreted as indices when applied to arrays.
+func ParsePointer(s string) (Path, error) {
+	if s == "" {
+		return Path{}, nil
+	}
+	if !strings.HasPrefix(s, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q: must start with '/'", s)
+	}
+	tokens := strings.Split(s[1:], "/")
+	path := make(Path, len(tokens))
+	for i, token := range tokens {
+		token = strings.ReplaceAll(token, "~1", "/")
+		token = strings.ReplaceAll(token, "~0", "~")
+		path[i] = token
+	}
+	return path, nil
+}
+
+// formatValue formats a value for error messages.
+func formatValue(v any) string {
+	text, err := MarshalTextValue(v)
+	if err != nil {
+		return fmt.Sprintf("%#v", v)
+	}
+	return string(text)
+}
+
+func isPathPrefix(prefix, p Path) bool {
+	if len(prefix) > len(p) {
+		return false
+	}
+	for i := range prefix {
+		if fmt.Sprint(prefix[i]) != fmt.Sprint(p[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// copyValue returns a deep copy of the dictionaries and arrays
+// within a value.
+func copyValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, x := range v {
+			m[k] = copyValue(x)
+		}
+		return m
+	case []any:
+		a := make([]any, len(v))
+		for i, x := range v {
+			a[i] = copyValue(x)
+		}
+		return a
+	}
+	return value
+}
@@ -0,0 +1,114 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ggdict_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/fzipp/gg/ggdict"
+)
+
+func TestPatchApply(t *testing.T) {
+	dict := testDict()
+	patch := ggdict.Patch{
+		{Op: "test", Path: "/objects/0/name", Value: "door"},
+		{Op: "replace", Path: "/objects/0/zsort", Value: 8},
+		{Op: "add", Path: "/objects/1", Value: map[string]any{"name": "key"}},
+		{Op: "add", Path: "/objects/-", Value: map[string]any{"name": "last"}},
+		{Op: "copy", From: "/name", Path: "/objects/0/room"},
+		{Op: "move", From: "/objects/2/zsort", Path: "/rope~1zsort"},
+		{Op: "remove", Path: "/objects/2"},
+	}
+	patched, err := patch.Apply(dict)
+	if err != nil {
+		t.Errorf("applying patch returned an error: %s", err)
+		return
+	}
+	want := map[string]any{
+		"name": "Room",
+		"objects": []any{
+			map[string]any{"name": "door", "zsort": 8, "room": "Room"},
+			map[string]any{"name": "key"},
+			map[string]any{"name": "last"},
+		},
+		"rope/zsort": 4,
+	}
+	if !reflect.DeepEqual(patched, want) {
+		t.Errorf("applying patch resulted in %#v, want: %#v", patched, want)
+	}
+	if !reflect.DeepEqual(dict, testDict()) {
+		t.Errorf("applying patch modified the original dictionary: %#v", dict)
+	}
+}
+
+func TestPatchApplyErrors(t *testing.T) {
+	tests := []struct {
+		patch     ggdict.Patch
+		wantError string
+	}{
+		{
+			ggdict.Patch{{Op: "test", Path: "/objects/0/zsort", Value: 3.0}},
+			"patch operation 0 (test /objects/0/zsort): test failed: value is 3, want: 3.0",
+		},
+		{
+			ggdict.Patch{{Op: "replace", Path: "/objects/0/pos", Value: 1}},
+			"patch operation 0 (replace /objects/0/pos): no such key: objects[0].pos",
+		},
+		{
+			ggdict.Patch{{Op: "remove", Path: "/objects/5"}},
+			`patch operation 0 (remove /objects/5): index out of range: objects[5]`,
+		},
+		{
+			ggdict.Patch{
+				{Op: "remove", Path: "/name"},
+				{Op: "move", From: "/objects", Path: "/objects/0/x"},
+			},
+			"patch operation 1 (move /objects/0/x): cannot move a value into one of its children",
+		},
+		{
+			ggdict.Patch{{Op: "rename", Path: "/name"}},
+			`patch operation 0 (rename /name): unknown operation: "rename"`,
+		},
+		{
+			ggdict.Patch{{Op: "add", Path: "name", Value: 1}},
+			`patch operation 0 (add name): invalid JSON pointer "name": must start with '/'`,
+		},
+	}
+	for _, tt := range tests {
+		_, err := tt.patch.Apply(testDict())
+		if err == nil {
+			t.Errorf("expected error for applying patch %#v, but no error returned", tt.patch)
+			continue
+		}
+		if err.Error() != tt.wantError {
+			t.Errorf("error message for applying patch %#v was: %q, want: %q", tt.patch, err.Error(), tt.wantError)
+		}
+	}
+}
+
+func TestPatchUnmarshalJSON(t *testing.T) {
+	data := `[
+		{"op": "add", "path": "/a", "value": 3},
+		{"op": "add", "path": "/b", "value": [3.0, {"$type": "coordinate", "$value": "{1,2}"}]},
+		{"op": "move", "from": "/a", "path": "/c"},
+		{"op": "remove", "path": "/b"}
+	]`
+	var patch ggdict.Patch
+	if err := json.Unmarshal([]byte(data), &patch); err != nil {
+		t.Errorf("unmarshalling patch returned an error: %s", err)
+		return
+	}
+	want := ggdict.Patch{
+		{Op: "add", Path: "/a", Value: 3},
+		{Op: "add", Path: "/b", Value: []any{3.0, ggdict.Coordinate("{1,2}")}},
+		{Op: "move", From: "/a", Path: "/c"},
+		{Op: "remove", Path: "/b"},
+	}
+	if !reflect.DeepEqual(patch, want) {
+		t.Errorf("unmarshalling patch resulted in %#v, want: %#v", patch, want)
+	}
+}
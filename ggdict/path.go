@@ -0,0 +1,325 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ggdict
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/scanner"
+)
+
+// Path is the location of a value within a decoded dictionary.
+// Each element is either a string (a dictionary key) or an int
+// (an array index). The empty path refers to the root dictionary.
+type Path []any
+
+// ParsePath parses a path expression like "objects[3].hotspot".
+// Keys that are not identifiers must be quoted and put in brackets,
+// e.g. `layers[0]["key with spaces"]`.
+func ParsePath(s string) (Path, error) {
+	var sc scanner.Scanner
+	sc.Init(strings.NewReader(s))
+	sc.Mode = scanner.ScanIdents | scanner.ScanInts | scanner.ScanStrings | scanner.ScanRawStrings
+	var scanErr error
+	sc.Error = func(s *scanner.Scanner, msg string) {
+		if scanErr == nil {
+			scanErr = fmt.Errorf("invalid path: %s", msg)
+		}
+	}
+	path := Path{}
+	tok := sc.Scan()
+	if tok == scanner.Ident {
+		path = append(path, sc.TokenText())
+		tok = sc.Scan()
+	}
+	for ; tok != scanner.EOF && scanErr == nil; tok = sc.Scan() {
+		switch tok {
+		case '.':
+			if sc.Scan() != scanner.Ident {
+				return nil, fmt.Errorf("invalid path %q: expected key after '.'", s)
+			}
+			path = append(path, sc.TokenText())
+		case '[':
+			switch sc.Scan() {
+			case scanner.Int:
+				i, err := strconv.Atoi(sc.TokenText())
+				if err != nil {
+					return nil, fmt.Errorf("invalid path %q: invalid index %s", s, sc.TokenText())
+				}
+				path = append(path, i)
+			case scanner.String, scanner.RawString:
+				key, err := strconv.Unquote(sc.TokenText())
+				if err != nil {
+					return nil, fmt.Errorf("invalid path %q: invalid key %s", s, sc.TokenText())
+				}
+				path = append(path, key)
+			default:
+				return nil, fmt.Errorf("invalid path %q: expected index or quoted key after '['", s)
+			}
+			if sc.Scan() != ']' {
+				return nil, fmt.Errorf("invalid path %q: expected ']'", s)
+			}
+		default:
+			return nil, fmt.Errorf("invalid path %q: unexpected %s", s, sc.TokenText())
+		}
+	}
+	if scanErr != nil {
+		return nil, scanErr
+	}
+	return path, nil
+}
+
+// ParseAssignment parses an assignment "path_expr=value" of a value in the
+// GGDictionary text format to a path, e.g. `actors.ray._room="Diner"` or
+// `["key=value"].pos=coord("{1,2}")`. The path expression ends at the first
+// equals sign that is not part of a quoted key.
+func ParseAssignment(s string) (Path, any, error) {
+	expr, valueText, ok := splitAssignment(s)
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid assignment %q: expected path_expr=value", s)
+	}
+	p, err := ParsePath(expr)
+	if err != nil {
+		return nil, nil, err
+	}
+	v, err := UnmarshalTextValue([]byte(valueText))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid assignment %q: %w", s, err)
+	}
+	return p, v, nil
+}
+
+func splitAssignment(s string) (expr, value string, ok bool) {
+	var quote rune
+	escaped := false
+	for i, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0 && r == '\\' && quote == '"':
+			escaped = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+		case r == '"' || r == '`':
+			quote = r
+		case r == '=':
+			return strings.TrimSpace(s[:i]), s[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+// String returns the path expression for the path,
+// which can be parsed by ParsePath.
+func (p Path) String() string {
+	var sb strings.Builder
+	for _, elem := range p {
+		switch e := elem.(type) {
+		case int:
+			sb.WriteString("[" + strconv.Itoa(e) + "]")
+		case string:
+			if !isBareKey(e) {
+				sb.WriteString("[" + strconv.Quote(e) + "]")
+				continue
+			}
+			if sb.Len() > 0 {
+				sb.WriteByte('.')
+			}
+			sb.WriteString(e)
+		}
+	}
+	return sb.String()
+}
+
+// Get returns the value at path p within the value root.
+func Get(root any, p Path) (any, error) {
+	v := root
+	resolved := Path{}
+	for _, elem := range p {
+		var err error
+		v, resolved, err = child(v, elem, resolved)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// Set sets the value at path p within the dictionary root. The parent of
+// the value must exist. If the parent is a dictionary, the key is added if
+// it does not exist yet. If the parent is an array, the index must be
+// within its bounds.
+func Set(root map[string]any, p Path, value any) error {
+	if len(p) == 0 {
+		return errors.New("cannot set root")
+	}
+	return modifyParent(root, p, func(parent any, elem any, resolved Path) (any, error) {
+		switch c := parent.(type) {
+		case map[string]any:
+			key, err := keyElem(elem, resolved)
+			if err != nil {
+				return nil, err
+			}
+			c[key] = value
+			return c, nil
+		case []any:
+			i, err := indexElem(elem, len(c), resolved)
+			if err != nil {
+				return nil, err
+			}
+			c[i] = value
+			return c, nil
+		}
+		return nil, notAContainer(resolved)
+	})
+}
+
+// Delete removes the value at path p within the dictionary root.
+// Elements of an array after a removed element are shifted to the left.
+func Delete(root map[string]any, p Path) error {
+	if len(p) == 0 {
+		return errors.New("cannot delete root")
+	}
+	return modifyParent(root, p, func(parent any, elem any, resolved Path) (any, error) {
+		switch c := parent.(type) {
+		case map[string]any:
+			key, err := keyElem(elem, resolved)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := c[key]; !ok {
+				return nil, fmt.Errorf("no such key: %s", append(resolved, key))
+			}
+			delete(c, key)
+			return c, nil
+		case []any:
+			i, err := indexElem(elem, len(c), resolved)
+			if err != nil {
+				return nil, err
+			}
+			return append(c[:i:i], c[i+1:]...), nil
+		}
+		return nil, notAContainer(resolved)
+	})
+}
+
+// insert inserts a value at path p within the dictionary root. If the parent
+// is an array, the value is inserted before the index, which may also be the
+// length of the array or "-" to append it.
+func insert(root map[string]any, p Path, value any) error {
+	return modifyParent(root, p, func(parent any, elem any, resolved Path) (any, error) {
+		switch c := parent.(type) {
+		case map[string]any:
+			key, err := keyElem(elem, resolved)
+			if err != nil {
+				return nil, err
+			}
+			c[key] = value
+			return c, nil
+		case []any:
+			if elem == "-" {
+				return append(c, value), nil
+			}
+			i, err := indexElem(elem, len(c)+1, resolved)
+			if err != nil {
+				return nil, err
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = value
+			return c, nil
+		}
+		return nil, notAContainer(resolved)
+	})
+}
+
+// modifyParent applies the function f to the parent container of the value
+// at path p, and replaces the parent with the container returned by f.
+// The resolved path of the parent is passed to f for error messages.
+func modifyParent(root any, p Path, f func(parent any, elem any, resolved Path) (any, error)) error {
+	_, err := modifyParentAt(root, p, Path{}, f)
+	return err
+}
+
+func modifyParentAt(v any, p Path, resolved Path, f func(parent any, elem any, resolved Path) (any, error)) (any, error) {
+	if len(resolved) == len(p)-1 {
+		return f(v, p[len(resolved)], resolved)
+	}
+	c, childPath, err := child(v, p[len(resolved)], resolved)
+	if err != nil {
+		return nil, err
+	}
+	newChild, err := modifyParentAt(c, p, childPath, f)
+	if err != nil {
+		return nil, err
+	}
+	switch x := v.(type) {
+	case map[string]any:
+		x[childPath[len(childPath)-1].(string)] = newChild
+	case []any:
+		x[childPath[len(childPath)-1].(int)] = newChild
+	}
+	return v, nil
+}
+
+// child returns the child of v referenced by the path element elem,
+// and the resolved path of the child, where array indices are ints.
+func child(v any, elem any, resolved Path) (any, Path, error) {
+	switch c := v.(type) {
+	case map[string]any:
+		key, err := keyElem(elem, resolved)
+		if err != nil {
+			return nil, nil, err
+		}
+		childPath := append(resolved[:len(resolved):len(resolved)], key)
+		x, ok := c[key]
+		if !ok {
+			return nil, nil, fmt.Errorf("no such key: %s", childPath)
+		}
+		return x, childPath, nil
+	case []any:
+		i, err := indexElem(elem, len(c), resolved)
+		if err != nil {
+			return nil, nil, err
+		}
+		return c[i], append(resolved[:len(resolved):len(resolved)], i), nil
+	}
+	return nil, nil, notAContainer(resolved)
+}
+
+func keyElem(elem any, resolved Path) (string, error) {
+	key, ok := elem.(string)
+	if !ok {
+		return "", fmt.Errorf("not an array: %s", resolved)
+	}
+	return key, nil
+}
+
+// indexElem returns the array index for a path element, which is either an
+// int or a string with a decimal number, as it is found in JSON pointers.
+func indexElem(elem any, length int, resolved Path) (int, error) {
+	var i int
+	switch e := elem.(type) {
+	case int:
+		i = e
+	case string:
+		var err error
+		i, err = strconv.Atoi(e)
+		if err != nil || e != strconv.Itoa(i) {
+			return 0, fmt.Errorf("not a dictionary: %s", resolved)
+		}
+	}
+	if i < 0 || i >= length {
+		return 0, fmt.Errorf("index out of range: %s", append(resolved[:len(resolved):len(resolved)], i))
+	}
+	return i, nil
+}
+
+func notAContainer(p Path) error {
+	return fmt.Errorf("not a dictionary or array: %s", p)
+}
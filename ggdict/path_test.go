@@ -0,0 +1,211 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ggdict_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fzipp/gg/ggdict"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		expr string
+		want ggdict.Path
+	}{
+		{"", ggdict.Path{}},
+		{"name", ggdict.Path{"name"}},
+		{"objects[3].hotspot", ggdict.Path{"objects", 3, "hotspot"}},
+		{".objects[3]", ggdict.Path{"objects", 3}},
+		{`[0]["key with spaces"].a`, ggdict.Path{0, "key with spaces", "a"}},
+	}
+	for _, tt := range tests {
+		path, err := ggdict.ParsePath(tt.expr)
+		if err != nil {
+			t.Errorf("parsing path %q returned an error: %s", tt.expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(path, tt.want) {
+			t.Errorf("parsing path %q resulted in %#v, want: %#v", tt.expr, path, tt.want)
+		}
+	}
+}
+
+func TestParsePathErrors(t *testing.T) {
+	tests := []struct {
+		expr      string
+		wantError string
+	}{
+		{"a.", `invalid path "a.": expected key after '.'`},
+		{"a[", `invalid path "a[": expected index or quoted key after '['`},
+		{"a[1", `invalid path "a[1": expected ']'`},
+		{"a b", `invalid path "a b": unexpected b`},
+	}
+	for _, tt := range tests {
+		_, err := ggdict.ParsePath(tt.expr)
+		if err == nil {
+			t.Errorf("expected error for parsing path %q, but no error returned", tt.expr)
+			continue
+		}
+		if err.Error() != tt.wantError {
+			t.Errorf("error message for parsing path %q was: %q, want: %q", tt.expr, err.Error(), tt.wantError)
+		}
+	}
+}
+
+func TestParseAssignment(t *testing.T) {
+	tests := []struct {
+		assignment string
+		wantPath   ggdict.Path
+		wantValue  any
+	}{
+		{"a=5", ggdict.Path{"a"}, 5},
+		{" objects[3].name = \"x=y\"", ggdict.Path{"objects", 3, "name"}, "x=y"},
+		{`["key=value"].pos=coord("{1,2}")`, ggdict.Path{"key=value", "pos"}, ggdict.Coordinate("{1,2}")},
+		{`["a\"="]=2.5`, ggdict.Path{`a"=`}, 2.5},
+		{"[`=`]=[1]", ggdict.Path{"="}, []any{1}},
+	}
+	for _, tt := range tests {
+		path, value, err := ggdict.ParseAssignment(tt.assignment)
+		if err != nil {
+			t.Errorf("parsing assignment %q returned an error: %s", tt.assignment, err)
+			continue
+		}
+		if !reflect.DeepEqual(path, tt.wantPath) {
+			t.Errorf("path of assignment %q was %#v, want: %#v", tt.assignment, path, tt.wantPath)
+		}
+		if !reflect.DeepEqual(value, tt.wantValue) {
+			t.Errorf("value of assignment %q was %#v, want: %#v", tt.assignment, value, tt.wantValue)
+		}
+	}
+}
+
+func TestParseAssignmentErrors(t *testing.T) {
+	tests := []struct {
+		assignment string
+		wantError  string
+	}{
+		{"a", `invalid assignment "a": expected path_expr=value`},
+		{`["a=1"]`, `invalid assignment "[\"a=1\"]": expected path_expr=value`},
+		{"a.=1", `invalid path "a.": expected key after '.'`},
+	}
+	for _, tt := range tests {
+		_, _, err := ggdict.ParseAssignment(tt.assignment)
+		if err == nil {
+			t.Errorf("expected error for parsing assignment %q, but no error returned", tt.assignment)
+			continue
+		}
+		if err.Error() != tt.wantError {
+			t.Errorf("error message for parsing assignment %q was: %q, want: %q", tt.assignment, err.Error(), tt.wantError)
+		}
+	}
+}
+
+func TestPathString(t *testing.T) {
+	tests := []struct {
+		path ggdict.Path
+		want string
+	}{
+		{ggdict.Path{}, ""},
+		{ggdict.Path{"objects", 3, "hotspot"}, "objects[3].hotspot"},
+		{ggdict.Path{0, "key with spaces", "a"}, `[0]["key with spaces"].a`},
+	}
+	for _, tt := range tests {
+		if s := tt.path.String(); s != tt.want {
+			t.Errorf("string for path %#v was %q, want: %q", tt.path, s, tt.want)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	dict := testDict()
+	tests := []struct {
+		expr      string
+		want      any
+		wantError string
+	}{
+		{"name", "Room", ""},
+		{"objects[1].zsort", 4, ""},
+		{"objects[1]", map[string]any{"name": "rope", "zsort": 4}, ""},
+		{"objects[2]", nil, "index out of range: objects[2]"},
+		{"objects[0].pos", nil, "no such key: objects[0].pos"},
+		{"name.x", nil, "not a dictionary or array: name"},
+		{"objects.x", nil, "not a dictionary: objects"},
+		{"objects[0][1]", nil, "not an array: objects[0]"},
+	}
+	for _, tt := range tests {
+		path, err := ggdict.ParsePath(tt.expr)
+		if err != nil {
+			t.Errorf("parsing path %q returned an error: %s", tt.expr, err)
+			continue
+		}
+		v, err := ggdict.Get(dict, path)
+		if tt.wantError != "" {
+			if err == nil || err.Error() != tt.wantError {
+				t.Errorf("error for getting %q was: %v, want: %q", tt.expr, err, tt.wantError)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("getting %q returned an error: %s", tt.expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(v, tt.want) {
+			t.Errorf("getting %q resulted in %#v, want: %#v", tt.expr, v, tt.want)
+		}
+	}
+}
+
+func TestSetAndDelete(t *testing.T) {
+	dict := testDict()
+	steps := []struct {
+		op    string
+		expr  string
+		value any
+	}{
+		{"set", "objects[0].zsort", 7},
+		{"set", "objects[1].pos", ggdict.Coordinate("{1,2}")},
+		{"delete", "objects[1].zsort", nil},
+		{"delete", "objects[0]", nil},
+		{"set", "name", "Other"},
+	}
+	for _, step := range steps {
+		path, err := ggdict.ParsePath(step.expr)
+		if err != nil {
+			t.Errorf("parsing path %q returned an error: %s", step.expr, err)
+			return
+		}
+		switch step.op {
+		case "set":
+			err = ggdict.Set(dict, path, step.value)
+		case "delete":
+			err = ggdict.Delete(dict, path)
+		}
+		if err != nil {
+			t.Errorf("%s %q returned an error: %s", step.op, step.expr, err)
+			return
+		}
+	}
+	want := map[string]any{
+		"name": "Other",
+		"objects": []any{
+			map[string]any{"name": "rope", "pos": ggdict.Coordinate("{1,2}")},
+		},
+	}
+	if !reflect.DeepEqual(dict, want) {
+		t.Errorf("set and delete resulted in %#v, want: %#v", dict, want)
+	}
+}
+
+func testDict() map[string]any {
+	return map[string]any{
+		"name": "Room",
+		"objects": []any{
+			map[string]any{"name": "door", "zsort": 3},
+			map[string]any{"name": "rope", "zsort": 4},
+		},
+	}
+}
@@ -23,7 +23,33 @@ func TestRoundTrip(t *testing.T) {
 		"nothing": nil,
 	}
 	format := ggdict.FormatThimbleweed
-	data := ggdict.Marshal(dict, format)
+	data, err := ggdict.Marshal(dict, format)
+	if err != nil {
+		t.Errorf("Marshal returned an error: %s", err)
+		return
+	}
+	newDict, err := ggdict.Unmarshal(data, format)
+	if err != nil {
+		t.Errorf("Unmarshal returned an error: %s", err)
+		return
+	}
+	if !reflect.DeepEqual(dict, newDict) {
+		t.Errorf("Marshal/unmarshal round trip resulted in\n%#v, want:\n%#v", newDict, dict)
+	}
+}
+
+func TestRoundTripCoordinateTypes(t *testing.T) {
+	dict := map[string]any{
+		"pos":     ggdict.Coordinate("{213,118}"),
+		"hotspot": ggdict.CoordinatePair("{{-23,-20},{17,20}}"),
+		"polygon": ggdict.CoordinateList("{82,94};{134,94}"),
+	}
+	format := ggdict.FormatMonkey
+	data, err := ggdict.Marshal(dict, format)
+	if err != nil {
+		t.Errorf("Marshal returned an error: %s", err)
+		return
+	}
 	newDict, err := ggdict.Unmarshal(data, format)
 	if err != nil {
 		t.Errorf("Unmarshal returned an error: %s", err)
@@ -0,0 +1,254 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ggdict
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind is the kind of value a Schema accepts.
+type Kind int
+
+const (
+	// KindAny accepts any value.
+	KindAny Kind = iota
+	KindNull
+	KindString
+	KindInt
+	KindFloat
+	// KindNumber accepts both integers and floats.
+	KindNumber
+	KindDictionary
+	KindArray
+	KindCoordinate
+	KindCoordinatePair
+	KindCoordinateList
+)
+
+var kindNames = map[Kind]string{
+	KindAny:            "any",
+	KindNull:           "null",
+	KindString:         "string",
+	KindInt:            "int",
+	KindFloat:          "float",
+	KindNumber:         "number",
+	KindDictionary:     "dictionary",
+	KindArray:          "array",
+	KindCoordinate:     "coordinate",
+	KindCoordinatePair: "coordinate pair",
+	KindCoordinateList: "coordinate list",
+}
+
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// kindOf returns the kind of a decoded value.
+func kindOf(value any) (Kind, bool) {
+	switch value.(type) {
+	case nil:
+		return KindNull, true
+	case string:
+		return KindString, true
+	case int:
+		return KindInt, true
+	case float64:
+		return KindFloat, true
+	case map[string]any:
+		return KindDictionary, true
+	case []any:
+		return KindArray, true
+	case Coordinate:
+		return KindCoordinate, true
+	case CoordinatePair:
+		return KindCoordinatePair, true
+	case CoordinateList:
+		return KindCoordinateList, true
+	}
+	return 0, false
+}
+
+// Schema describes the structure of a decoded dictionary or of a value
+// within it.
+type Schema struct {
+	// Kind is the kind of value the schema accepts.
+	// It is ignored if OneOf is set.
+	Kind Kind
+
+	// Fields are the known keys of a dictionary and their schemas.
+	Fields map[string]Field
+
+	// Values is the schema for the values of dictionary keys that are not
+	// in Fields. If Values is nil, keys that are not in Fields are reported
+	// as unknown keys, unless Fields is nil as well, in which case any keys
+	// and values are accepted.
+	Values *Schema
+
+	// Elem is the schema for the elements of an array. If Elem is nil,
+	// any elements are accepted.
+	Elem *Schema
+
+	// OneOf is a list of alternative schemas. A value is valid if it is
+	// valid according to at least one of them.
+	OneOf []*Schema
+}
+
+// Field is a known key of a dictionary schema.
+type Field struct {
+	Schema   *Schema
+	Required bool
+}
+
+// ValidationError is a violation of a schema at a path within a dictionary.
+type ValidationError struct {
+	Path    Path
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Path) == 0 {
+		return e.Message
+	}
+	return e.Path.String() + ": " + e.Message
+}
+
+// ValidationErrors is the list of all violations of a schema found
+// during a validation.
+type ValidationErrors []*ValidationError
+
+func (list ValidationErrors) Error() string {
+	switch len(list) {
+	case 0:
+		return "no errors"
+	case 1:
+		return list[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", list[0], len(list)-1)
+}
+
+// Validate checks a decoded value against the schema. It reports missing
+// required keys, values of the wrong kind and unknown keys with their
+// paths. If the value is not valid, the returned error is of type
+// ValidationErrors.
+func (s *Schema) Validate(value any) error {
+	errs := s.validate(Path{}, value)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func (s *Schema) validate(p Path, value any) ValidationErrors {
+	if s == nil {
+		return nil
+	}
+	if len(s.OneOf) > 0 {
+		return s.validateOneOf(p, value)
+	}
+	kind, ok := kindOf(value)
+	if !ok {
+		return ValidationErrors{{Path: p, Message: fmt.Sprintf("unsupported value type %T", value)}}
+	}
+	if !s.Kind.accepts(kind) {
+		return ValidationErrors{{Path: p, Message: fmt.Sprintf("expected %s, found %s", s.Kind, kind)}}
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		return s.validateDictionary(p, v)
+	case []any:
+		var errs ValidationErrors
+		for i, elem := range v {
+			errs = append(errs, s.Elem.validate(appendPath(p, i), elem)...)
+		}
+		return errs
+	}
+	return nil
+}
+
+func (s *Schema) validateDictionary(p Path, dict map[string]any) ValidationErrors {
+	var errs ValidationErrors
+	for _, k := range sortedFieldKeys(s.Fields) {
+		if _, ok := dict[k]; !ok && s.Fields[k].Required {
+			errs = append(errs, &ValidationError{Path: appendPath(p, k), Message: "missing required key"})
+		}
+	}
+	for _, k := range sortedKeys(dict) {
+		if field, ok := s.Fields[k]; ok {
+			errs = append(errs, field.Schema.validate(appendPath(p, k), dict[k])...)
+			continue
+		}
+		if s.Values != nil {
+			errs = append(errs, s.Values.validate(appendPath(p, k), dict[k])...)
+			continue
+		}
+		if s.Fields != nil {
+			errs = append(errs, &ValidationError{Path: appendPath(p, k), Message: "unknown key"})
+		}
+	}
+	return errs
+}
+
+// validateOneOf validates a value against alternative schemas. If the value
+// is invalid according to all of them, the errors of the first alternative
+// that accepts the kind of the value are reported, since it is most likely
+// the intended one.
+func (s *Schema) validateOneOf(p Path, value any) ValidationErrors {
+	var candidate ValidationErrors
+	for _, alt := range s.OneOf {
+		errs := alt.validate(p, value)
+		if len(errs) == 0 {
+			return nil
+		}
+		kind, _ := kindOf(value)
+		if candidate == nil && (len(alt.OneOf) > 0 || alt.Kind.accepts(kind)) {
+			candidate = errs
+		}
+	}
+	if candidate != nil {
+		return candidate
+	}
+	kind, ok := kindOf(value)
+	if !ok {
+		return ValidationErrors{{Path: p, Message: fmt.Sprintf("unsupported value type %T", value)}}
+	}
+	return ValidationErrors{{Path: p, Message: fmt.Sprintf("expected %s, found %s", s.oneOfKinds(), kind)}}
+}
+
+func (s *Schema) oneOfKinds() string {
+	names := make([]string, len(s.OneOf))
+	for i, alt := range s.OneOf {
+		if len(alt.OneOf) > 0 {
+			names[i] = alt.oneOfKinds()
+			continue
+		}
+		names[i] = alt.Kind.String()
+	}
+	if len(names) == 1 {
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
+
+func (k Kind) accepts(kind Kind) bool {
+	switch k {
+	case KindAny:
+		return true
+	case KindNumber:
+		return kind == KindInt || kind == KindFloat
+	}
+	return k == kind
+}
+
+func sortedFieldKeys(fields map[string]Field) []string {
+	keys := make(map[string]any, len(fields))
+	for k := range fields {
+		keys[k] = nil
+	}
+	return sortedKeys(keys)
+}
@@ -0,0 +1,198 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ggdict_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/fzipp/gg/ggdict"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	schema := &ggdict.Schema{
+		Kind: ggdict.KindDictionary,
+		Fields: map[string]ggdict.Field{
+			"name": {Schema: &ggdict.Schema{Kind: ggdict.KindString}, Required: true},
+			"objects": {Schema: &ggdict.Schema{
+				Kind: ggdict.KindArray,
+				Elem: &ggdict.Schema{
+					Kind: ggdict.KindDictionary,
+					Fields: map[string]ggdict.Field{
+						"name":  {Schema: &ggdict.Schema{Kind: ggdict.KindString}, Required: true},
+						"zsort": {Schema: &ggdict.Schema{Kind: ggdict.KindInt}},
+						"pos": {Schema: &ggdict.Schema{OneOf: []*ggdict.Schema{
+							{Kind: ggdict.KindString},
+							{Kind: ggdict.KindCoordinate},
+						}}},
+					},
+				},
+			}},
+			"globals": {Schema: &ggdict.Schema{
+				Kind:   ggdict.KindDictionary,
+				Values: &ggdict.Schema{Kind: ggdict.KindNumber},
+			}},
+		},
+	}
+	tests := []struct {
+		dict       map[string]any
+		wantErrors []string
+	}{
+		{testDict(), nil},
+		{
+			map[string]any{
+				"name":    "Room",
+				"objects": []any{map[string]any{"name": "door", "pos": ggdict.Coordinate("{1,2}")}},
+				"globals": map[string]any{"a": 1, "b": 2.5},
+			},
+			nil,
+		},
+		{
+			map[string]any{
+				"objects": []any{
+					map[string]any{"name": "door", "zsort": 3.0},
+					map[string]any{"zsort": 4, "pos": 5, "hotspot": "{}"},
+				},
+				"globals": map[string]any{"a": "x"},
+				"sheet":   "RoomSheet",
+			},
+			[]string{
+				"name: missing required key",
+				`globals.a: expected number, found string`,
+				"objects[0].zsort: expected int, found float",
+				"objects[1].name: missing required key",
+				"objects[1].hotspot: unknown key",
+				"objects[1].pos: expected string or coordinate, found int",
+				"sheet: unknown key",
+			},
+		},
+	}
+	for _, tt := range tests {
+		err := schema.Validate(tt.dict)
+		var got []string
+		if err != nil {
+			var errs ggdict.ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Errorf("validation error for %#v was of type %T, want: ValidationErrors", tt.dict, err)
+				continue
+			}
+			for _, e := range errs {
+				got = append(got, e.Error())
+			}
+		}
+		if !reflect.DeepEqual(got, tt.wantErrors) {
+			t.Errorf("validation errors for %#v were %q, want: %q", tt.dict, got, tt.wantErrors)
+		}
+	}
+}
+
+func TestSchemaValidateRootKind(t *testing.T) {
+	schema := &ggdict.Schema{Kind: ggdict.KindDictionary}
+	err := schema.Validate([]any{1})
+	want := "expected dictionary, found array"
+	if err == nil || err.Error() != want {
+		t.Errorf("validation error was %v, want: %q", err, want)
+	}
+}
+
+func TestSchemaByNameUnknown(t *testing.T) {
+	if _, ok := ggdict.SchemaByName("bogus"); ok {
+		t.Errorf("schema %q was found, want: not found", "bogus")
+	}
+}
+
+func TestKnownSchemas(t *testing.T) {
+	tests := []struct {
+		schema     string
+		dict       map[string]any
+		wantErrors string
+	}{
+		{
+			"wimpy",
+			map[string]any{
+				"name":       "Room",
+				"sheet":      "RoomSheet",
+				"background": "bg",
+				"roomsize":   ggdict.Coordinate("{320,180}"),
+				"layers": []any{
+					map[string]any{"name": []any{"fg1", "fg2"}, "parallax": 1.5, "zsort": 5},
+				},
+				"objects": []any{
+					map[string]any{
+						"name":    "door",
+						"hotspot": "{{-23,-20},{17,20}}",
+						"pos":     "{10,20}",
+						"usedir":  "DIR_LEFT",
+						"usepos":  "{1,2}",
+						"zsort":   3,
+						"animations": []any{
+							map[string]any{
+								"name":   "open",
+								"frames": []any{"door_open"},
+								"layers": []any{map[string]any{"name": "l", "frames": []any{}}},
+							},
+						},
+					},
+				},
+				"scaling":   []any{"0.5@10", map[string]any{"scaling": []any{"1@100"}, "trigger": "t"}},
+				"walkboxes": []any{map[string]any{"polygon": ggdict.CoordinateList("{1,2};{3,4};{5,6}")}},
+			},
+			"",
+		},
+		{
+			"wimpy",
+			map[string]any{
+				"name":     "Room",
+				"sheet":    "RoomSheet",
+				"roomsize": "{320,180}",
+				"objects": []any{
+					map[string]any{
+						"name":       "door",
+						"hotspot":    "{{-23,-20},{17,20}}",
+						"pos":        "{10,20}",
+						"usedir":     "DIR_LEFT",
+						"usepos":     "{1,2}",
+						"zsort":      3,
+						"animations": []any{map[string]any{"name": "open", "layers": []any{map[string]any{"fps": "x"}}}},
+					},
+				},
+			},
+			"objects[0].animations[0].layers[0].name: missing required key (and 1 more errors)",
+		},
+		{
+			"directory",
+			map[string]any{"files": []any{map[string]any{"filename": "a.wimpy", "offset": 0, "size": "1"}}},
+			"files[0].size: expected int, found string",
+		},
+		{
+			"animation",
+			map[string]any{"animations": []any{}},
+			"",
+		},
+		{
+			"savegame",
+			map[string]any{"version": 2, "savebuild": 958, "savetime": 1600000000, "gameTime": 1.5, "bogus": 1},
+			"bogus: unknown key",
+		},
+	}
+	for _, tt := range tests {
+		schema, ok := ggdict.SchemaByName(tt.schema)
+		if !ok {
+			t.Errorf("schema %q not found", tt.schema)
+			continue
+		}
+		err := schema.Validate(tt.dict)
+		if tt.wantErrors == "" {
+			if err != nil {
+				t.Errorf("validation of %#v with schema %q returned an error: %s", tt.dict, tt.schema, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tt.wantErrors {
+			t.Errorf("validation error of %#v with schema %q was %v, want: %q", tt.dict, tt.schema, err, tt.wantErrors)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ggdict
+
+import "sort"
+
+// Stats are statistics about data in the GGDictionary format.
+type Stats struct {
+	// Size is the size of the data in bytes.
+	Size int
+	// Values is the number of values by kind, including dictionaries
+	// and arrays.
+	Values map[Kind]int
+	// MaxDepth is the maximum nesting depth of dictionaries and arrays.
+	// The root dictionary has depth 1.
+	MaxDepth int
+	// StringTableSize is the size of the string offsets table and the
+	// strings in bytes.
+	StringTableSize int
+	// Strings are the strings of the string table with their number of
+	// references, most frequently referenced first.
+	Strings []StringCount
+	// NumberStrings is the number of strings in the string table that
+	// represent integers or floats.
+	NumberStrings int
+	// NumberStringBytes is the number of bytes taken up by the number
+	// strings in the string table, including their offsets.
+	NumberStringBytes int
+}
+
+// StringCount is a string of the string table and the number of times it
+// is referenced as a dictionary key or a value.
+type StringCount struct {
+	Value string
+	Count int
+}
+
+// Analyze decodes data in the GGDictionary format and collects
+// statistics about it.
+func Analyze(data []byte, f Format) (*Stats, error) {
+	c := &statsCollector{}
+	_, u, err := unmarshal(data, f, unmarshalOptions{stats: c})
+	if err != nil {
+		return nil, err
+	}
+	stats := &Stats{
+		Size:            len(data),
+		Values:          c.values,
+		MaxDepth:        c.maxDepth,
+		StringTableSize: len(data) - u.stringOffsetsStart,
+		Strings:         make([]StringCount, len(c.references)),
+	}
+	for i, count := range c.references {
+		stats.Strings[i] = StringCount{Value: u.stringAt(i), Count: count}
+	}
+	for _, n := range c.numbers {
+		stats.NumberStrings++
+		// string offset, string and terminating zero byte
+		stats.NumberStringBytes += 4 + len(n) + 1
+	}
+	sort.SliceStable(stats.Strings, func(i, j int) bool {
+		return stats.Strings[i].Count > stats.Strings[j].Count
+	})
+	return stats, nil
+}
+
+// statsCollector collects statistics while unmarshalling.
+type statsCollector struct {
+	values     map[Kind]int
+	depth      int
+	maxDepth   int
+	references []int
+	numbers    map[int]string
+}
+
+func (c *statsCollector) init(offs offsets) {
+	c.values = make(map[Kind]int)
+	c.references = make([]int, len(offs))
+	c.numbers = make(map[int]string)
+}
+
+var valueTypeKinds = map[valueType]Kind{
+	typeNull:           KindNull,
+	typeDictionary:     KindDictionary,
+	typeArray:          KindArray,
+	typeString:         KindString,
+	typeInteger:        KindInt,
+	typeFloat:          KindFloat,
+	typeCoordinate:     KindCoordinate,
+	typeCoordinatePair: KindCoordinatePair,
+	typeCoordinateList: KindCoordinateList,
+}
+
+func (c *statsCollector) countValue(t valueType) {
+	if kind, ok := valueTypeKinds[t]; ok {
+		c.values[kind]++
+	}
+}
+
+// enter is called when a dictionary or an array is entered. It returns
+// a function to be called when it is left.
+func (c *statsCollector) enter() (leave func()) {
+	c.depth++
+	if c.depth > c.maxDepth {
+		c.maxDepth = c.depth
+	}
+	return func() { c.depth-- }
+}
+
+func (c *statsCollector) countReference(strIndex int) {
+	c.references[strIndex]++
+}
+
+func (c *statsCollector) countNumber(strIndex int, s string) {
+	c.numbers[strIndex] = s
+}
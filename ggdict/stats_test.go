@@ -0,0 +1,66 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ggdict_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fzipp/gg/ggdict"
+)
+
+func TestAnalyze(t *testing.T) {
+	dict := map[string]any{
+		"name": "door",
+		"objects": []any{
+			map[string]any{"name": "door", "zsort": 3, "pos": ggdict.Coordinate("{1,2}")},
+			map[string]any{"name": "rope", "zsort": 3, "fps": 1.5},
+		},
+		"nothing": nil,
+	}
+	data, err := ggdict.Marshal(dict, ggdict.FormatMonkey)
+	if err != nil {
+		t.Errorf("marshalling %#v returned an error: %s", dict, err)
+		return
+	}
+	stats, err := ggdict.Analyze(data, ggdict.FormatMonkey)
+	if err != nil {
+		t.Errorf("analyzing data returned an error: %s", err)
+		return
+	}
+	want := &ggdict.Stats{
+		Size: len(data),
+		Values: map[ggdict.Kind]int{
+			ggdict.KindDictionary: 3,
+			ggdict.KindArray:      1,
+			ggdict.KindString:     3,
+			ggdict.KindInt:        2,
+			ggdict.KindFloat:      1,
+			ggdict.KindNull:       1,
+			ggdict.KindCoordinate: 1,
+		},
+		MaxDepth: 3,
+		// offsets: marker, 11 offsets, end marker; strings: marker, strings
+		StringTableSize: (1 + 11*4 + 4) + (1 + 57),
+		Strings: []ggdict.StringCount{
+			{"name", 3},
+			{"door", 2},
+			{"zsort", 2},
+			{"3", 2},
+			{"nothing", 1},
+			{"objects", 1},
+			{"pos", 1},
+			{"{1,2}", 1},
+			{"fps", 1},
+			{"1.5", 1},
+			{"rope", 1},
+		},
+		NumberStrings:     2,
+		NumberStringBytes: (4 + 2) + (4 + 4),
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("analyzing data resulted in\n%#v, want:\n%#v", stats, want)
+	}
+}
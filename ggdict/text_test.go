@@ -0,0 +1,155 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ggdict_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fzipp/gg/ggdict"
+)
+
+func TestMarshalText(t *testing.T) {
+	tests := []struct {
+		dict map[string]any
+		want string
+	}{
+		{nil, "{}\n"},
+		{map[string]any{
+			"name":     "Test \"1\"",
+			"count":    4,
+			"ratio":    2.0,
+			"numbers":  []any{0.5, 3, -2.5, nil},
+			"empty":    []any{},
+			"pos":      ggdict.Coordinate("{213,118}"),
+			"hotspot":  ggdict.CoordinatePair("{{-23,-20},{17,20}}"),
+			"polygon":  ggdict.CoordinateList("{82,94};{134,94}"),
+			"key 2":    map[string]any{},
+			"children": []any{map[string]any{"id": 0}},
+		}, `{
+  children: [
+    {
+      id: 0
+    }
+  ]
+  count: 4
+  empty: []
+  hotspot: coordpair("{{-23,-20},{17,20}}")
+  "key 2": {}
+  name: "Test \"1\""
+  numbers: [0.5, 3, -2.5, null]
+  polygon: coordlist("{82,94};{134,94}")
+  pos: coord("{213,118}")
+  ratio: 2.0
+}
+`},
+	}
+	for _, tt := range tests {
+		data, err := ggdict.MarshalText(tt.dict)
+		if err != nil {
+			t.Errorf("text marshalling of %#v returned an error: %s", tt.dict, err)
+			continue
+		}
+		if string(data) != tt.want {
+			t.Errorf("text marshalling of %#v was:\n%s, want:\n%s", tt.dict, data, tt.want)
+		}
+	}
+}
+
+func TestUnmarshalText(t *testing.T) {
+	tests := []struct {
+		text string
+		want map[string]any
+	}{
+		{"{}", map[string]any{}},
+		{`// A comment
+{
+  a: 1, b: 1.0, c: -2, d: +2.5e2 /* inline comment */
+  "e f": "text"
+  g: ` + "`raw`" + `
+  h: [null, 0x10, coord("{1,2}"), coordpair("{{1,2},{3,4}}"), coordlist("{1,2};{3,4}")]
+  i: {j: []}
+}`, map[string]any{
+			"a":   1,
+			"b":   1.0,
+			"c":   -2,
+			"d":   250.0,
+			"e f": "text",
+			"g":   "raw",
+			"h": []any{
+				nil, 16,
+				ggdict.Coordinate("{1,2}"),
+				ggdict.CoordinatePair("{{1,2},{3,4}}"),
+				ggdict.CoordinateList("{1,2};{3,4}"),
+			},
+			"i": map[string]any{"j": []any{}},
+		}},
+	}
+	for _, tt := range tests {
+		dict, err := ggdict.UnmarshalText([]byte(tt.text))
+		if err != nil {
+			t.Errorf("text unmarshalling of %q returned an error: %s", tt.text, err)
+			continue
+		}
+		if !reflect.DeepEqual(dict, tt.want) {
+			t.Errorf("text unmarshalling of %q was:\n%#v, want:\n%#v", tt.text, dict, tt.want)
+		}
+	}
+}
+
+func TestUnmarshalTextErrors(t *testing.T) {
+	tests := []struct {
+		text      string
+		wantError string
+	}{
+		{"", "text:1:1: expected value, found end of input"},
+		{"[1]", "root is not a dictionary"},
+		{"{} {}", "text:1:4: expected end of input, found {"},
+		{"{a 1}", "text:1:4: expected ':', found 1"},
+		{"{a: 1, a: 2}", "text:1:8: duplicate key \"a\""},
+		{"{a: [1, 2}", "text:1:10: expected value, found }"},
+		{"{a: coord(1)}", "text:1:11: expected string literal, found 1"},
+		{"{a: - x}", "text:1:7: expected number, found x"},
+		{"{\n  a: true\n}", "text:2:6: expected value, found true"},
+		{"{a: \"x}", "text:1:5: literal not terminated"},
+	}
+	for _, tt := range tests {
+		_, err := ggdict.UnmarshalText([]byte(tt.text))
+		if err == nil {
+			t.Errorf("expected error for text unmarshalling of %q, but no error returned", tt.text)
+			continue
+		}
+		if err.Error() != tt.wantError {
+			t.Errorf("error message for text unmarshalling of %q was: %q, want: %q", tt.text, err.Error(), tt.wantError)
+		}
+	}
+}
+
+func TestTextRoundTrip(t *testing.T) {
+	dict := map[string]any{
+		"name":    "Test",
+		"count":   4,
+		"numbers": []any{0.5, 3, 2.0, 1e21, -7},
+		"subobject": map[string]any{
+			"title": "Test 2",
+			"id":    0,
+			"pos":   ggdict.Coordinate("{1,2}"),
+		},
+		"nothing": nil,
+	}
+	data, err := ggdict.MarshalText(dict)
+	if err != nil {
+		t.Errorf("MarshalText returned an error: %s", err)
+		return
+	}
+	newDict, err := ggdict.UnmarshalText(data)
+	if err != nil {
+		t.Errorf("UnmarshalText returned an error: %s", err)
+		return
+	}
+	if !reflect.DeepEqual(dict, newDict) {
+		t.Errorf("text marshal/unmarshal round trip resulted in\n%#v, want:\n%#v", newDict, dict)
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ggdict
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// MarshalText encodes a dictionary in the GGDictionary text format.
+// See UnmarshalText for a description of the syntax.
+//
+// Unlike JSON, the text format keeps the distinction between integers,
+// floats and the coordinate types, so that a dictionary can be converted
+// to text and back without changing the types of its values.
+func MarshalText(dict map[string]any) ([]byte, error) {
+	p := &textPrinter{}
+	if dict == nil {
+		dict = map[string]any{}
+	}
+	if err := p.printValue(dict); err != nil {
+		return nil, err
+	}
+	p.buf.WriteByte('\n')
+	return p.buf.Bytes(), nil
+}
+
+// MarshalTextValue encodes a single value, which may also be an array
+// or a dictionary, in the GGDictionary text format.
+func MarshalTextValue(value any) ([]byte, error) {
+	p := &textPrinter{}
+	if err := p.printValue(value); err != nil {
+		return nil, err
+	}
+	return p.buf.Bytes(), nil
+}
+
+// maxInlineArrayLen is the maximum length of an array of scalar values that
+// is printed on a single line.
+const maxInlineArrayLen = 72
+
+type textPrinter struct {
+	buf    bytes.Buffer
+	indent int
+}
+
+func (p *textPrinter) printValue(value any) error {
+	switch v := value.(type) {
+	case nil:
+		p.buf.WriteString("null")
+	case map[string]any:
+		return p.printDictionary(v)
+	case []any:
+		return p.printArray(v)
+	case string:
+		p.buf.WriteString(strconv.Quote(v))
+	case int:
+		p.buf.WriteString(strconv.Itoa(v))
+	case int32:
+		p.buf.WriteString(strconv.Itoa(int(v)))
+	case int64:
+		p.buf.WriteString(strconv.Itoa(int(v)))
+	case uint32:
+		p.buf.WriteString(strconv.Itoa(int(v)))
+	case uint64:
+		p.buf.WriteString(strconv.Itoa(int(v)))
+	case float64:
+		p.buf.WriteString(formatFloatLiteral(v))
+	case float32:
+		p.buf.WriteString(formatFloatLiteral(float64(v)))
+	case Coordinate:
+		p.printCoordinate(textCoordinate, string(v))
+	case CoordinatePair:
+		p.printCoordinate(textCoordinatePair, string(v))
+	case CoordinateList:
+		p.printCoordinate(textCoordinateList, string(v))
+	default:
+		return fmt.Errorf("unsupported value type: %T", value)
+	}
+	return nil
+}
+
+func (p *textPrinter) printDictionary(d map[string]any) error {
+	if len(d) == 0 {
+		p.buf.WriteString("{}")
+		return nil
+	}
+	p.buf.WriteString("{\n")
+	p.indent++
+	for _, k := range sortedKeys(d) {
+		p.printIndent()
+		p.printKey(k)
+		p.buf.WriteString(": ")
+		if err := p.printValue(d[k]); err != nil {
+			return fmt.Errorf("key %q: %w", k, err)
+		}
+		p.buf.WriteByte('\n')
+	}
+	p.indent--
+	p.printIndent()
+	p.buf.WriteByte('}')
+	return nil
+}
+
+func (p *textPrinter) printArray(a []any) error {
+	if len(a) == 0 {
+		p.buf.WriteString("[]")
+		return nil
+	}
+	if inline, ok := inlineArray(a); ok {
+		p.buf.WriteString(inline)
+		return nil
+	}
+	p.buf.WriteString("[\n")
+	p.indent++
+	for i, v := range a {
+		p.printIndent()
+		if err := p.printValue(v); err != nil {
+			return fmt.Errorf("index %d: %w", i, err)
+		}
+		p.buf.WriteByte('\n')
+	}
+	p.indent--
+	p.printIndent()
+	p.buf.WriteByte(']')
+	return nil
+}
+
+// inlineArray returns the single line representation of an array,
+// if it only contains scalar values and is short enough.
+func inlineArray(a []any) (string, bool) {
+	ip := &textPrinter{}
+	ip.buf.WriteByte('[')
+	for i, v := range a {
+		switch v.(type) {
+		case map[string]any, []any:
+			return "", false
+		}
+		if i > 0 {
+			ip.buf.WriteString(", ")
+		}
+		if err := ip.printValue(v); err != nil {
+			return "", false
+		}
+		if ip.buf.Len() > maxInlineArrayLen {
+			return "", false
+		}
+	}
+	ip.buf.WriteByte(']')
+	return ip.buf.String(), true
+}
+
+func (p *textPrinter) printKey(k string) {
+	if isBareKey(k) {
+		p.buf.WriteString(k)
+		return
+	}
+	p.buf.WriteString(strconv.Quote(k))
+}
+
+func (p *textPrinter) printCoordinate(name, s string) {
+	p.buf.WriteString(name)
+	p.buf.WriteByte('(')
+	p.buf.WriteString(strconv.Quote(s))
+	p.buf.WriteByte(')')
+}
+
+func (p *textPrinter) printIndent() {
+	p.buf.WriteString(strings.Repeat("  ", p.indent))
+}
+
+// isBareKey reports whether a dictionary key can be written without quotes.
+func isBareKey(k string) bool {
+	if k == "" {
+		return false
+	}
+	for i, r := range k {
+		if r == '_' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r)) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// formatFloatLiteral formats a float so that it is always recognized
+// as a float and not as an integer, e.g. 2 is formatted as "2.0".
+func formatFloatLiteral(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return textNaN
+	case math.IsInf(f, 1):
+		return textInf
+	case math.IsInf(f, -1):
+		return "-" + textInf
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
@@ -0,0 +1,306 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ggdict
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"text/scanner"
+)
+
+const (
+	textNull           = "null"
+	textInf            = "inf"
+	textNaN            = "nan"
+	textCoordinate     = "coord"
+	textCoordinatePair = "coordpair"
+	textCoordinateList = "coordlist"
+)
+
+// UnmarshalText decodes a dictionary from the GGDictionary text format
+// as produced by MarshalText.
+//
+// The syntax is similar to JSON, but with typed number literals,
+// coordinate literals and comments:
+//
+//	// A comment
+//	{
+//	  name: "Example"
+//	  zsort: 5
+//	  fps: 10.0
+//	  pos: coord("{213,118}")
+//	  hotspot: coordpair("{{-23,-20},{17,20}}")
+//	  polygon: coordlist("{82,94};{134,94};{142,91}")
+//	  "key with spaces": [1, 2.5, null]
+//	}
+//
+// The EBNF grammar:
+//
+//	Dictionary = "{" { Entry [ "," ] } "}" .
+//	Entry      = (identifier | string_lit) ":" Value .
+//	Array      = "[" { Value [ "," ] } "]" .
+//	Value      = Dictionary | Array | string_lit | Number | Coordinate | "null" .
+//	Number     = [ "+" | "-" ] (int_lit | float_lit | "inf" | "nan") .
+//	Coordinate = ("coord" | "coordpair" | "coordlist") "(" string_lit ")" .
+//
+// Identifiers, string literals, int literals, float literals and comments
+// follow the Go syntax. A number literal with a decimal point or an exponent
+// is a float, otherwise it is an integer.
+func UnmarshalText(data []byte) (map[string]any, error) {
+	p := newTextParser(data)
+	root, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectEOF(); err != nil {
+		return nil, err
+	}
+	dict, ok := root.(map[string]any)
+	if !ok {
+		return nil, errors.New("root is not a dictionary")
+	}
+	return dict, nil
+}
+
+// UnmarshalTextValue decodes a single value, which may also be an array
+// or a dictionary, from the GGDictionary text format,
+// e.g. `5`, `2.0`, `"text"` or `coord("{213,118}")`.
+func UnmarshalTextValue(data []byte) (any, error) {
+	p := newTextParser(data)
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectEOF(); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+type textParser struct {
+	scanner scanner.Scanner
+	err     error
+	pos     scanner.Position
+	tok     rune
+	lit     string
+}
+
+func newTextParser(data []byte) *textParser {
+	p := &textParser{}
+	p.scanner.Init(bytes.NewReader(data))
+	p.scanner.Filename = "text"
+	p.scanner.Mode = scanner.ScanIdents | scanner.ScanInts | scanner.ScanFloats |
+		scanner.ScanStrings | scanner.ScanRawStrings | scanner.ScanComments |
+		scanner.SkipComments
+	p.scanner.Error = func(s *scanner.Scanner, msg string) {
+		if p.err == nil {
+			p.err = newTextError(s.Position, msg)
+		}
+	}
+	p.next()
+	return p
+}
+
+func (p *textParser) next() {
+	p.tok = p.scanner.Scan()
+	p.pos = p.scanner.Position
+	if !p.pos.IsValid() {
+		p.pos = p.scanner.Pos()
+	}
+	p.lit = p.scanner.TokenText()
+}
+
+func (p *textParser) parseValue() (any, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	switch p.tok {
+	case '{':
+		return p.parseDictionary()
+	case '[':
+		return p.parseArray()
+	case scanner.String, scanner.RawString:
+		return p.parseString()
+	case scanner.Int, scanner.Float, '-', '+':
+		return p.parseNumber()
+	case scanner.Ident:
+		switch p.lit {
+		case textNull:
+			p.next()
+			return nil, nil
+		case textInf, textNaN:
+			return p.parseNumber()
+		case textCoordinate:
+			s, err := p.parseCoordinate()
+			return Coordinate(s), err
+		case textCoordinatePair:
+			s, err := p.parseCoordinate()
+			return CoordinatePair(s), err
+		case textCoordinateList:
+			s, err := p.parseCoordinate()
+			return CoordinateList(s), err
+		}
+	}
+	return nil, p.unexpected("value")
+}
+
+func (p *textParser) parseDictionary() (map[string]any, error) {
+	p.next() // '{'
+	dict := make(map[string]any)
+	for p.tok != '}' {
+		var key string
+		keyPos := p.pos
+		switch p.tok {
+		case scanner.Ident:
+			key = p.lit
+			p.next()
+		case scanner.String, scanner.RawString:
+			var err error
+			key, err = p.parseString()
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, p.unexpected("dictionary key or '}'")
+		}
+		if _, exists := dict[key]; exists {
+			return nil, newTextError(keyPos, fmt.Sprintf("duplicate key %q", key))
+		}
+		if err := p.expect(':'); err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		dict[key] = value
+		if p.tok == ',' {
+			p.next()
+		}
+	}
+	p.next() // '}'
+	return dict, p.err
+}
+
+func (p *textParser) parseArray() ([]any, error) {
+	p.next() // '['
+	array := make([]any, 0)
+	for p.tok != ']' {
+		if p.tok == scanner.EOF {
+			return nil, p.unexpected("value or ']'")
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, value)
+		if p.tok == ',' {
+			p.next()
+		}
+	}
+	p.next() // ']'
+	return array, p.err
+}
+
+func (p *textParser) parseString() (string, error) {
+	s, err := strconv.Unquote(p.lit)
+	if err != nil {
+		return "", newTextError(p.pos, fmt.Sprintf("invalid string literal %s", p.lit))
+	}
+	p.next()
+	return s, nil
+}
+
+func (p *textParser) parseNumber() (any, error) {
+	sign := ""
+	if p.tok == '-' || p.tok == '+' {
+		sign = p.lit
+		p.next()
+	}
+	pos, lit := p.pos, p.lit
+	switch {
+	case p.tok == scanner.Int:
+		p.next()
+		i, err := strconv.ParseInt(sign+lit, 0, 0)
+		if err != nil {
+			return nil, newTextError(pos, fmt.Sprintf("invalid integer literal %s%s", sign, lit))
+		}
+		return int(i), nil
+	case p.tok == scanner.Float:
+		p.next()
+		f, err := strconv.ParseFloat(sign+lit, 64)
+		if err != nil {
+			return nil, newTextError(pos, fmt.Sprintf("invalid float literal %s%s", sign, lit))
+		}
+		return f, nil
+	case p.tok == scanner.Ident && lit == textInf:
+		p.next()
+		if sign == "-" {
+			return math.Inf(-1), nil
+		}
+		return math.Inf(1), nil
+	case p.tok == scanner.Ident && lit == textNaN:
+		p.next()
+		return math.NaN(), nil
+	}
+	return nil, p.unexpected("number")
+}
+
+func (p *textParser) parseCoordinate() (string, error) {
+	p.next() // coordinate type name
+	if err := p.expect('('); err != nil {
+		return "", err
+	}
+	if p.tok != scanner.String && p.tok != scanner.RawString {
+		return "", p.unexpected("string literal")
+	}
+	s, err := p.parseString()
+	if err != nil {
+		return "", err
+	}
+	if err := p.expect(')'); err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+func (p *textParser) expect(tok rune) error {
+	if p.err != nil {
+		return p.err
+	}
+	if p.tok != tok {
+		return p.unexpected(strconv.QuoteRune(tok))
+	}
+	p.next()
+	return nil
+}
+
+func (p *textParser) expectEOF() error {
+	if p.err != nil {
+		return p.err
+	}
+	if p.tok != scanner.EOF {
+		return p.unexpected("end of input")
+	}
+	return nil
+}
+
+func (p *textParser) unexpected(want string) error {
+	if p.err != nil {
+		return p.err
+	}
+	found := p.lit
+	if p.tok == scanner.EOF {
+		found = "end of input"
+	}
+	return newTextError(p.pos, fmt.Sprintf("expected %s, found %s", want, found))
+}
+
+func newTextError(pos scanner.Position, msg string) error {
+	return fmt.Errorf("%s: %s", pos, msg)
+}
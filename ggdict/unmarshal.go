@@ -11,63 +11,145 @@ import (
 )
 
 func Unmarshal(data []byte, f Format) (map[string]any, error) {
-	u := &unmarshaller{
-		buf:    data,
-		format: f,
+	root, _, err := unmarshal(data, f, unmarshalOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return root.(map[string]any), nil
+}
+
+// UnmarshalKeys decodes only the values of the given keys of the root
+// dictionary of GGDict data. The values of all other keys are skipped
+// without being decoded, which is faster than Unmarshal for large
+// dictionaries if only a few values are needed. Keys that are not present
+// are missing from the returned dictionary.
+func UnmarshalKeys(data []byte, f Format, keys ...string) (map[string]any, error) {
+	wanted := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		wanted[key] = true
+	}
+	root, _, err := unmarshal(data, f, unmarshalOptions{keys: wanted})
+	if err != nil {
+		return nil, err
+	}
+	return root.(map[string]any), nil
+}
+
+type unmarshalOptions struct {
+	// stats collects statistics about the data if it is not nil.
+	stats *statsCollector
+	// ordered decodes dictionaries as *orderedDict instead of map[string]any.
+	ordered bool
+	// keys restricts decoding to these keys of the root dictionary
+	// if it is not nil.
+	keys map[string]bool
+}
+
+// unmarshal is the same as Unmarshal, but it additionally returns the state
+// of the unmarshaller after reading the root dictionary.
+func unmarshal(data []byte, f Format, opts unmarshalOptions) (root any, u *unmarshaller, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			de, ok := r.(decodeError)
+			if !ok {
+				panic(r)
+			}
+			root, u, err = nil, nil, de.err
+		}
+	}()
+
+	u = &unmarshaller{
+		buf:     data,
+		format:  f,
+		stats:   opts.stats,
+		ordered: opts.ordered,
 	}
 
 	signature := u.readRawUint32()
 	if signature != formatSignature {
-		return nil, fmt.Errorf("invalid format signature: %#x", signature)
+		return nil, nil, fmt.Errorf("invalid format signature: %#x", signature)
 	}
 
 	// Unused, as far as known. Always 1. Maybe format version?
 	_ = u.readRawUint32()
 
-	stringOffsetsStart := u.readRawUint32()
+	u.stringOffsetsStart = u.readRawUint32()
 	ou := &unmarshaller{
 		buf:    data,
-		offset: stringOffsetsStart,
+		offset: u.stringOffsetsStart,
 		format: f,
 	}
 	stringOffsets, err := ou.readValue()
 	if err != nil {
-		return nil, fmt.Errorf("could not read string offsets: %w", err)
+		return nil, nil, fmt.Errorf("could not read string offsets: %w", err)
 	}
 
 	offs, ok := stringOffsets.(offsets)
 	if !ok {
-		return nil, errors.New("read value is not a string offsets table")
+		return nil, nil, errors.New("read value is not a string offsets table")
 	}
 	u.stringOffsets = offs
-	root, err := u.readValue()
+	if u.stats != nil {
+		u.stats.init(offs)
+	}
+	if opts.keys != nil {
+		root, err = u.readRootKeys(opts.keys)
+	} else {
+		root, err = u.readValue()
+	}
 	if err != nil {
-		return nil, fmt.Errorf("could not read root: %w", err)
+		return nil, nil, fmt.Errorf("could not read root: %w", err)
 	}
-	dict, ok := root.(map[string]any)
-	if !ok {
-		return nil, errors.New("root is not a dictionary")
+	switch root.(type) {
+	case map[string]any, *orderedDict:
+		return root, u, nil
 	}
-	return dict, nil
+	return nil, nil, errors.New("root is not a dictionary")
 }
 
+// decodeError is raised as panic by the unmarshaller if it encounters
+// invalid data, and recovered by unmarshal.
+type decodeError struct {
+	err error
+}
+
+var errUnexpectedEnd = decodeError{errors.New("unexpected end of data")}
+
 type unmarshaller struct {
-	buf           []byte
-	offset        int
-	stringOffsets offsets
-	format        Format
+	buf                []byte
+	offset             int
+	stringOffsetsStart int
+	stringOffsets      offsets
+	format             Format
+	// hasCoordinates is set if a value with a coordinate type was read.
+	hasCoordinates bool
+	stats          *statsCollector
+	ordered        bool
 }
 
 func (u *unmarshaller) readValue() (any, error) {
-	switch valueType := u.readTypeMarker(); valueType {
+	valueType := u.readTypeMarker()
+	if u.stats != nil {
+		u.stats.countValue(valueType)
+	}
+	switch valueType {
 	case typeNull:
 		return nil, nil
 	case typeDictionary:
 		return u.readDictionary()
 	case typeArray:
 		return u.readArray()
-	case typeString, typeCoordinate, typeCoordinatePair, typeCoordinateList:
+	case typeString:
 		return u.readString(), nil
+	case typeCoordinate:
+		u.hasCoordinates = true
+		return Coordinate(u.readString()), nil
+	case typeCoordinatePair:
+		u.hasCoordinates = true
+		return CoordinatePair(u.readString()), nil
+	case typeCoordinateList:
+		u.hasCoordinates = true
+		return CoordinateList(u.readString()), nil
 	case typeInteger:
 		return u.readInteger()
 	case typeFloat:
@@ -83,25 +165,100 @@ func (u *unmarshaller) readTypeMarker() valueType {
 	return valueType(u.readRawByte())
 }
 
-func (u *unmarshaller) readDictionary() (map[string]any, error) {
-	length := u.readRawUint32()
+func (u *unmarshaller) readDictionary() (any, error) {
+	if u.stats != nil {
+		defer u.stats.enter()()
+	}
+	length := u.readLength()
 	dictionary := make(map[string]any, length)
+	var keys []string
 	for i := 0; i < length; i++ {
 		key := u.readString()
 		value, err := u.readValue()
 		if err != nil {
 			return nil, fmt.Errorf("could not read dictionary value for key %q: %w", key, err)
 		}
+		if _, dup := dictionary[key]; u.ordered && !dup {
+			keys = append(keys, key)
+		}
 		dictionary[key] = value
 	}
 	if u.readTypeMarker() != typeDictionary {
 		return nil, fmt.Errorf("unterminated dictionary")
 	}
+	if u.ordered {
+		return &orderedDict{keys: keys, values: dictionary}, nil
+	}
 	return dictionary, nil
 }
 
+// readRootKeys reads the root dictionary, but only decodes the values of
+// the given keys and skips the other values.
+func (u *unmarshaller) readRootKeys(keys map[string]bool) (any, error) {
+	if u.readTypeMarker() != typeDictionary {
+		return nil, errors.New("root is not a dictionary")
+	}
+	length := u.readLength()
+	dictionary := make(map[string]any, len(keys))
+	for i := 0; i < length; i++ {
+		key := u.readString()
+		if !keys[key] {
+			if err := u.skipValue(); err != nil {
+				return nil, fmt.Errorf("could not read dictionary value for key %q: %w", key, err)
+			}
+			continue
+		}
+		value, err := u.readValue()
+		if err != nil {
+			return nil, fmt.Errorf("could not read dictionary value for key %q: %w", key, err)
+		}
+		dictionary[key] = value
+	}
+	if u.readTypeMarker() != typeDictionary {
+		return nil, fmt.Errorf("unterminated dictionary")
+	}
+	return dictionary, nil
+}
+
+// skipValue reads over a value without decoding it.
+func (u *unmarshaller) skipValue() error {
+	valueType := u.readTypeMarker()
+	switch valueType {
+	case typeNull:
+	case typeDictionary:
+		length := u.readLength()
+		for i := 0; i < length; i++ {
+			u.readStringIndex()
+			if err := u.skipValue(); err != nil {
+				return err
+			}
+		}
+		if u.readTypeMarker() != typeDictionary {
+			return fmt.Errorf("unterminated dictionary")
+		}
+	case typeArray:
+		length := u.readLength()
+		for i := 0; i < length; i++ {
+			if err := u.skipValue(); err != nil {
+				return err
+			}
+		}
+		if u.readTypeMarker() != typeArray {
+			return fmt.Errorf("unterminated array")
+		}
+	case typeString, typeCoordinate, typeCoordinatePair, typeCoordinateList, typeInteger, typeFloat:
+		u.readStringIndex()
+	default:
+		return fmt.Errorf("unknown value type: %d", valueType)
+	}
+	return nil
+}
+
 func (u *unmarshaller) readArray() ([]any, error) {
-	length := u.readRawUint32()
+	if u.stats != nil {
+		defer u.stats.enter()()
+	}
+	length := u.readLength()
 	array := make([]any, length)
 	for i := 0; i < length; i++ {
 		value, err := u.readValue()
@@ -117,13 +274,30 @@ func (u *unmarshaller) readArray() ([]any, error) {
 }
 
 func (u *unmarshaller) readString() string {
+	return u.stringAt(u.readStringIndex())
+}
+
+func (u *unmarshaller) readStringIndex() int {
 	var strIndex int
 	if u.format.ShortStringIndices {
 		strIndex = u.readRawUint16()
 	} else {
 		strIndex = u.readRawUint32()
 	}
+	if strIndex >= len(u.stringOffsets) {
+		panic(decodeError{fmt.Errorf("string index out of range: %d", strIndex)})
+	}
+	if u.stats != nil {
+		u.stats.countReference(strIndex)
+	}
+	return strIndex
+}
+
+func (u *unmarshaller) stringAt(strIndex int) string {
 	startOffset := u.stringOffsets[strIndex]
+	if startOffset >= len(u.buf) {
+		panic(decodeError{fmt.Errorf("string offset out of range: %d", startOffset)})
+	}
 	endOffset := startOffset
 	for endOffset < len(u.buf) && u.buf[endOffset] != 0 {
 		endOffset++
@@ -132,35 +306,69 @@ func (u *unmarshaller) readString() string {
 }
 
 func (u *unmarshaller) readInteger() (int, error) {
-	return strconv.Atoi(u.readString())
+	return strconv.Atoi(u.readNumberString())
 }
 
 func (u *unmarshaller) readFloat() (float64, error) {
-	return strconv.ParseFloat(u.readString(), 64)
+	return strconv.ParseFloat(u.readNumberString(), 64)
+}
+
+// readNumberString reads the string representation of an integer
+// or a float.
+func (u *unmarshaller) readNumberString() string {
+	strIndex := u.readStringIndex()
+	s := u.stringAt(strIndex)
+	if u.stats != nil {
+		u.stats.countNumber(strIndex, s)
+	}
+	return s
 }
 
 func (u *unmarshaller) readStringOffsets() offsets {
 	var offs offsets
-	for byteOrder.Uint32(u.buf[u.offset:]) != 0xFFFFFFFF {
-		offs = append(offs, u.readRawUint32())
+	for {
+		offset := u.readRawUint32()
+		if offset == 0xFFFFFFFF {
+			break
+		}
+		offs = append(offs, offset)
 	}
 	return offs
 }
 
+// readLength reads the number of elements of a dictionary or an array.
+func (u *unmarshaller) readLength() int {
+	length := u.readRawUint32()
+	// Each element takes up at least one byte.
+	if length > len(u.buf)-u.offset {
+		panic(decodeError{fmt.Errorf("invalid length: %d", length)})
+	}
+	return length
+}
+
 func (u *unmarshaller) readRawUint32() int {
+	u.ensureAvailable(4)
 	i := int(byteOrder.Uint32(u.buf[u.offset:]))
 	u.offset += 4
 	return i
 }
 
 func (u *unmarshaller) readRawUint16() int {
+	u.ensureAvailable(2)
 	i := int(byteOrder.Uint16(u.buf[u.offset:]))
 	u.offset += 2
 	return i
 }
 
 func (u *unmarshaller) readRawByte() byte {
+	u.ensureAvailable(1)
 	b := u.buf[u.offset]
 	u.offset++
 	return b
 }
+
+func (u *unmarshaller) ensureAvailable(n int) {
+	if u.offset < 0 || u.offset+n > len(u.buf) {
+		panic(errUnexpectedEnd)
+	}
+}
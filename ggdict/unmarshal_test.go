@@ -5,6 +5,7 @@
 package ggdict_test
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/fzipp/gg/ggdict"
@@ -101,3 +102,41 @@ func TestUnmarshalErrors(t *testing.T) {
 		}
 	}
 }
+
+func TestUnmarshalKeys(t *testing.T) {
+	dict := map[string]any{
+		"name":    "Delores",
+		"time":    12.5,
+		"level":   3,
+		"nothing": nil,
+		"nested": map[string]any{
+			"list": []any{1, "two", map[string]any{"three": 3.0}},
+		},
+		"pos":  ggdict.Coordinate("{1,2}"),
+		"tags": []any{"a", "b"},
+	}
+	tests := []struct {
+		format ggdict.Format
+		keys   []string
+		want   map[string]any
+	}{
+		{ggdict.FormatThimbleweed, []string{"name", "level"}, map[string]any{"name": "Delores", "level": 3}},
+		{ggdict.FormatThimbleweed, []string{"tags", "missing"}, map[string]any{"tags": []any{"a", "b"}}},
+		{ggdict.FormatMonkey, []string{"time", "pos"}, map[string]any{"time": 12.5, "pos": ggdict.Coordinate("{1,2}")}},
+		{ggdict.FormatMonkey, nil, map[string]any{}},
+	}
+	for _, tt := range tests {
+		data, err := ggdict.Marshal(dict, tt.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ggdict.UnmarshalKeys(data, tt.format, tt.keys...)
+		if err != nil {
+			t.Errorf("unmarshalling keys %q failed: %v", tt.keys, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("unmarshalling keys %q was %v, want: %v", tt.keys, got, tt.want)
+		}
+	}
+}
@@ -110,7 +110,10 @@ func (p *Packer) Finish() error {
 		"files": p.files,
 	}
 	dirOffset := p.offset
-	data := ggdict.Marshal(dir, p.dictFormat)
+	data, err := ggdict.Marshal(dir, p.dictFormat)
+	if err != nil {
+		return fmt.Errorf("could not marshal directory: %w", err)
+	}
 	size := len(data)
 	n, err := p.xorKey.EncodingWriter(p.writer, int64(size)).Write(data)
 	p.offset += int64(n)
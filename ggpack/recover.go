@@ -0,0 +1,173 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ggpack
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/fzipp/gg/crypt/xor/twp"
+	"github.com/fzipp/gg/ggdict"
+)
+
+// directoryTemplate is the known plaintext at the start of a pack
+// directory, a GGDictionary of the form
+//
+//	{"files": [{"filename": …, "offset": …, "size": …}, …]}
+//
+// Unknown bytes are marked with -1. Integers that are indices into the
+// string table or counts are assumed to be less than 65536, so their
+// high bytes are known to be zero.
+var directoryTemplate = []int{
+	0x01, 0x02, 0x03, 0x04, // signature
+	0x01, 0x00, 0x00, 0x00, // version
+	-1, -1, -1, -1, // string offsets start
+	0x02,                   // dictionary
+	0x01, 0x00, 0x00, 0x00, // one key
+	-1, -1, 0x00, 0x00, // key "files"
+	0x03,               // array
+	-1, -1, 0x00, 0x00, // number of files
+	0x02,                   // dictionary
+	0x03, 0x00, 0x00, 0x00, // three keys
+	-1, -1, 0x00, 0x00, // first key
+}
+
+// fileHeaders are the known starts of files by file extension.
+var fileHeaders = map[string][]byte{
+	".png": {
+		0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n',
+		0x00, 0x00, 0x00, 0x0D, 'I', 'H', 'D', 'R',
+	},
+	// Beginning of stream page with granule position 0
+	".ogg": {
+		'O', 'g', 'g', 'S', 0x00, 0x02,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	},
+	".wav": {'R', 'I', 'F', 'F'},
+}
+
+// maxCheckedFileHeaders is the maximum number of file headers
+// that are checked to verify a key candidate.
+const maxCheckedFileHeaders = 16
+
+// RecoverKeys recovers the XOR key of a pack file that is encrypted with
+// a key like those of Thimbleweed Park and Delores, but with unknown magic
+// bytes or multiplier, e.g. after a game update.
+//
+// The known structure at the start of the pack directory is used as known
+// plaintext to derive key candidates via twp.RecoverKeys. A candidate is
+// verified by decoding the whole directory with it and by comparing the
+// starts of PNG, OGG and WAV files in the pack with their known headers.
+// The verified candidates are returned.
+func RecoverKeys(path string) ([]*twp.Key, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open file '%s': %w", path, err)
+	}
+	defer f.Close()
+	pack := &Pack{reader: f}
+	root, err := pack.readRootInfo()
+	if err != nil {
+		return nil, err
+	}
+	dirCiphertext, err := pack.readRaw(root.packOffset, root.size)
+	if err != nil {
+		return nil, fmt.Errorf("could not read directory bytes: %w", err)
+	}
+	candidates, err := twp.RecoverKeys([]twp.KnownPlaintext{
+		knownPlaintext(dirCiphertext, root.size, directoryTemplate),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var keys []*twp.Key
+	for _, key := range candidates {
+		dir, err := decodeDirectory(dirCiphertext, root, key)
+		if err != nil {
+			continue
+		}
+		ok, err := pack.checkFileHeaders(dir, key)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no key candidate could decode the pack directory")
+	}
+	return keys, nil
+}
+
+func knownPlaintext(ciphertext []byte, size int64, template []int) twp.KnownPlaintext {
+	kp := twp.KnownPlaintext{
+		Ciphertext: ciphertext,
+		Size:       size,
+		Plaintext:  make([]byte, len(template)),
+		Known:      make([]bool, len(template)),
+	}
+	for i, b := range template {
+		if b >= 0 {
+			kp.Plaintext[i] = byte(b)
+			kp.Known[i] = true
+		}
+	}
+	return kp
+}
+
+func decodeDirectory(ciphertext []byte, root *fileInfo, key *twp.Key) (*directory, error) {
+	buf := make([]byte, len(ciphertext))
+	_, err := io.ReadFull(key.DecodingReader(bytes.NewReader(ciphertext), root.size), buf)
+	if err != nil {
+		return nil, err
+	}
+	return readDirectory(buf, root, ggdict.FormatThimbleweed)
+}
+
+// checkFileHeaders reports whether the files with known headers
+// start with these headers when decoded with the key.
+func (p *Pack) checkFileHeaders(dir *directory, key *twp.Key) (bool, error) {
+	checked := 0
+	for _, entry := range dir.entries {
+		fi := entry.(*fileInfo)
+		header, ok := fileHeaders[filepath.Ext(fi.name)]
+		if !ok || fi.size < int64(len(header)) {
+			continue
+		}
+		ciphertext, err := p.readRaw(fi.packOffset, int64(len(header)))
+		if err != nil {
+			return false, fmt.Errorf("could not read file '%s': %w", fi.name, err)
+		}
+		decoded := make([]byte, len(header))
+		_, err = io.ReadFull(key.DecodingReader(bytes.NewReader(ciphertext), fi.size), decoded)
+		if err != nil {
+			return false, err
+		}
+		if !bytes.Equal(decoded, header) {
+			return false, nil
+		}
+		checked++
+		if checked == maxCheckedFileHeaders {
+			break
+		}
+	}
+	return true, nil
+}
+
+// readRaw reads bytes from the pack file without decoding them.
+func (p *Pack) readRaw(offset, size int64) ([]byte, error) {
+	_, err := p.reader.Seek(offset, io.SeekStart)
+	if err != nil {
+		return nil, fmt.Errorf("could not seek offset: %w", err)
+	}
+	buf := make([]byte, size)
+	_, err = io.ReadFull(p.reader, buf)
+	return buf, err
+}
@@ -0,0 +1,81 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ggpack_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/fzipp/gg/crypt/xor/twp"
+	"github.com/fzipp/gg/ggpack"
+)
+
+func TestRecoverKeys(t *testing.T) {
+	key := &twp.Key{
+		MagicBytes: []byte{
+			0x4F, 0xD0, 0xA0, 0xAC, 0x4A, 0x17, 0xB9, 0xE5,
+			0x93, 0x79, 0x45, 0xA5, 0xC1, 0x2E, 0x31, 0x93,
+		},
+		Multiplier: 0x3B,
+	}
+	png := append([]byte{
+		0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n',
+		0x00, 0x00, 0x00, 0x0D, 'I', 'H', 'D', 'R',
+	}, bytes.Repeat([]byte{0x42}, 100)...)
+
+	packFile := filepath.Join(t.TempDir(), "test.ggpack")
+	f, err := os.Create(packFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packer, err := ggpack.NewPacker(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packer.SetKey(key)
+	if err := packer.WriteFile(filepath.Join("testdata", "test.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := packer.Write("image.png", bytes.NewReader(png), int64(len(png))); err != nil {
+		t.Fatal(err)
+	}
+	if err := packer.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := ggpack.RecoverKeys(packFile)
+	if err != nil {
+		t.Errorf("recovering keys returned an error: %s", err)
+		return
+	}
+	// Keys with the multiplier m+128 and the highest bit of the odd magic
+	// bytes flipped are equivalent.
+	equivalent := &twp.Key{
+		MagicBytes: []byte{
+			0x4F, 0x50, 0xA0, 0x2C, 0x4A, 0x97, 0xB9, 0x65,
+			0x93, 0xF9, 0x45, 0x25, 0xC1, 0xAE, 0x31, 0x13,
+		},
+		Multiplier: 0xBB,
+	}
+	want := []*twp.Key{key, equivalent}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("recovering keys resulted in %v, want: %v", formatKeys(keys), formatKeys(want))
+	}
+}
+
+func formatKeys(keys []*twp.Key) []string {
+	s := make([]string, len(keys))
+	for i, k := range keys {
+		s[i] = fmt.Sprintf("{%X %#02x}", k.MagicBytes, k.Multiplier)
+	}
+	return s
+}
@@ -0,0 +1,139 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package savegame
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/fzipp/gg/crypt/xxtea"
+	"github.com/fzipp/gg/ggdict"
+)
+
+// ChecksumError is returned for savegame data with an invalid checksum.
+type ChecksumError struct {
+	// Stored is the checksum stored in the footer.
+	Stored uint32
+	// Computed is the checksum computed from the data.
+	Computed uint32
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("invalid checksum for savegame data: stored %#08x, computed %#08x",
+		e.Stored, e.Computed)
+}
+
+// Diagnosis is the result of decrypting savegame data with a format,
+// for finding out why the data cannot be read.
+type Diagnosis struct {
+	// Format is the format with which the data was decrypted.
+	Format Format
+	// Size is the size of the data.
+	Size int
+	// Signature are the first bytes of the decrypted data, which are the
+	// signature of a GGDictionary if the key is correct.
+	Signature []byte
+	// ValidSignature is true if Signature is a GGDictionary signature.
+	// If it is false, the data was probably encrypted with a different
+	// key, i.e. it is a savegame of a different game, or it is not a
+	// savegame at all.
+	ValidSignature bool
+	// StoredChecksum is the checksum stored in the footer.
+	StoredChecksum uint32
+	// ComputedChecksum is the checksum computed from the data.
+	ComputedChecksum uint32
+	// Footer are the decrypted footer bytes.
+	Footer []byte
+	// DictErr is the error decoding the GGDictionary, if any.
+	DictErr error
+}
+
+// ChecksumOk reports whether the stored checksum matches the computed
+// checksum.
+func (d *Diagnosis) ChecksumOk() bool {
+	return d.StoredChecksum == d.ComputedChecksum
+}
+
+// Ok reports whether the data can be read with the format.
+func (d *Diagnosis) Ok() bool {
+	return d.ValidSignature && d.ChecksumOk() && d.DictErr == nil
+}
+
+// Diagnose decrypts savegame data with each of the known Formats and
+// returns a diagnosis for each. The data is not modified.
+func Diagnose(data []byte) []*Diagnosis {
+	diagnoses := make([]*Diagnosis, len(Formats))
+	for i, f := range Formats {
+		diagnoses[i] = f.Diagnose(data)
+	}
+	return diagnoses
+}
+
+// ggdictSignature are the first bytes of a GGDictionary: the format
+// signature, followed by a 32-bit 1.
+var ggdictSignature = []byte{0x01, 0x02, 0x03, 0x04, 0x01, 0x00, 0x00, 0x00}
+
+// Diagnose decrypts savegame data with this format and returns the
+// diagnosis. The data is not modified.
+func (f Format) Diagnose(data []byte) *Diagnosis {
+	d := &Diagnosis{Format: f, Size: len(data)}
+	if len(data) < f.FooterSize+4 {
+		d.DictErr = fmt.Errorf("savegame data too short: %d bytes", len(data))
+		return d
+	}
+	plain := append([]byte(nil), data...)
+	xxtea.DecryptInPlace(plain, f.Key)
+	checksumIndex := len(plain) - f.FooterSize
+	n := len(ggdictSignature)
+	if n > checksumIndex {
+		n = checksumIndex
+	}
+	d.Signature = plain[:n]
+	d.ValidSignature = bytes.Equal(d.Signature, ggdictSignature)
+	d.Footer = plain[checksumIndex:]
+	d.StoredChecksum, d.ComputedChecksum = f.checksums(plain)
+	_, d.DictErr = ggdict.Unmarshal(plain, f.DictFormat)
+	return d
+}
+
+// Repair recomputes the checksum of savegame data, e.g. of a savegame
+// that was edited by hand, and returns the repaired data. The format is
+// detected by the GGDictionary signature of the decrypted data, trying
+// each of the known Formats. The data is not modified.
+func Repair(data []byte) ([]byte, Format, error) {
+	for _, f := range Formats {
+		repaired, err := f.Repair(data)
+		if errors.Is(err, errNoSignature) {
+			continue
+		}
+		return repaired, f, err
+	}
+	return nil, Format{}, errNoSignature
+}
+
+var errNoSignature = errors.New("decrypted savegame data has no GGDictionary signature (wrong key or game?)")
+
+// Repair recomputes the checksum of savegame data in this format and
+// returns the repaired data. The other bytes of the footer are kept.
+// It returns an error if the decrypted data has no GGDictionary signature
+// or if the repaired data cannot be decoded. The data is not modified.
+func (f Format) Repair(data []byte) ([]byte, error) {
+	if len(data) < f.FooterSize+len(ggdictSignature) {
+		return nil, fmt.Errorf("savegame data too short: %d bytes", len(data))
+	}
+	plain := append([]byte(nil), data...)
+	xxtea.DecryptInPlace(plain, f.Key)
+	if !bytes.HasPrefix(plain, ggdictSignature) {
+		return nil, errNoSignature
+	}
+	checksumIndex := len(plain) - f.FooterSize
+	endianness.PutUint32(plain[checksumIndex:], f.checksum(plain[:checksumIndex]))
+	if _, err := ggdict.Unmarshal(plain, f.DictFormat); err != nil {
+		return nil, fmt.Errorf("could not repair savegame data: %w", err)
+	}
+	xxtea.EncryptInPlace(plain, f.Key)
+	return plain, nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package savegame
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/fzipp/gg/crypt/xxtea"
+)
+
+// handEditedSavegame returns savegame data with a changed value,
+// but without an updated checksum.
+func handEditedSavegame(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	err := Write(&buf, map[string]any{"currentRoom": "Diner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	xxtea.DecryptInPlace(data, XXTEAKey)
+	i := bytes.Index(data, []byte("Diner"))
+	copy(data[i:], "Motel")
+	xxtea.EncryptInPlace(data, XXTEAKey)
+	return data
+}
+
+func TestDiagnose(t *testing.T) {
+	data := handEditedSavegame(t)
+
+	_, _, err := Decode(data)
+	var checksumErr *ChecksumError
+	if !errors.As(err, &checksumErr) {
+		t.Fatalf("error for hand-edited savegame was %v, want: checksum error", err)
+	}
+
+	diagnoses := Diagnose(data)
+	if len(diagnoses) != len(Formats) {
+		t.Fatalf("number of diagnoses was %d, want: %d", len(diagnoses), len(Formats))
+	}
+	d := diagnoses[0]
+	if !d.ValidSignature {
+		t.Errorf("signature %x is not valid, want: valid", d.Signature)
+	}
+	if d.ChecksumOk() || d.Ok() {
+		t.Errorf("checksum of hand-edited savegame is ok, want: not ok")
+	}
+	if d.StoredChecksum != checksumErr.Stored || d.ComputedChecksum != checksumErr.Computed {
+		t.Errorf("checksums were %#08x/%#08x, want: %#08x/%#08x",
+			d.StoredChecksum, d.ComputedChecksum, checksumErr.Stored, checksumErr.Computed)
+	}
+	if len(d.Footer) != FormatThimbleweed.FooterSize {
+		t.Errorf("footer length was %d, want: %d", len(d.Footer), FormatThimbleweed.FooterSize)
+	}
+	if d.DictErr != nil {
+		t.Errorf("dictionary error was %v, want: none", d.DictErr)
+	}
+
+	d = Diagnose(bytes.Repeat([]byte{0xAB}, 1024))[0]
+	if d.ValidSignature || d.Ok() {
+		t.Errorf("signature of random data %x is valid, want: not valid", d.Signature)
+	}
+}
+
+func TestRepair(t *testing.T) {
+	data := handEditedSavegame(t)
+	orig := append([]byte(nil), data...)
+	repaired, format, err := Repair(data)
+	if err != nil {
+		t.Fatalf("repairing savegame failed: %v", err)
+	}
+	if !bytes.Equal(data, orig) {
+		t.Errorf("repairing savegame modified the data")
+	}
+	if format.Name != FormatThimbleweed.Name {
+		t.Errorf("format of repaired savegame was %q, want: %q", format.Name, FormatThimbleweed.Name)
+	}
+	dict, err := Read(bytes.NewReader(repaired))
+	if err != nil {
+		t.Fatalf("reading repaired savegame failed: %v", err)
+	}
+	want := map[string]any{"currentRoom": "Motel"}
+	if !reflect.DeepEqual(dict, want) {
+		t.Errorf("repaired savegame was %v, want: %v", dict, want)
+	}
+
+	_, _, err = Repair(bytes.Repeat([]byte{0xAB}, 1024))
+	if !errors.Is(err, errNoSignature) {
+		t.Errorf("error for repairing random data was %v, want: %v", err, errNoSignature)
+	}
+}
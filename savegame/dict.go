@@ -0,0 +1,210 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package savegame
+
+import (
+	"fmt"
+	"math"
+)
+
+// keys records which modelled keys were present in a dictionary, which
+// of them were null and which of the numbers were integers, so that the
+// conversion back to a dictionary is lossless.
+type keys struct {
+	present map[string]bool
+	nulls   map[string]bool
+	ints    map[string]bool
+}
+
+// dictReader takes the values of modelled keys from a dictionary.
+// The remaining keys are returned by finish. The first type error
+// is recorded in err.
+type dictReader struct {
+	path string
+	rest map[string]any
+	keys keys
+	err  error
+}
+
+func newDictReader(path string, dict map[string]any) *dictReader {
+	return &dictReader{
+		path: path,
+		rest: copyDict(dict),
+		keys: keys{present: make(map[string]bool)},
+	}
+}
+
+// sub returns a reader for the dictionary of the given key.
+func (d *dictReader) sub(key string, dict map[string]any) *dictReader {
+	return newDictReader(d.path+"/"+key, dict)
+}
+
+// child returns a reader for an element of the given key, or nil if the
+// element is not a dictionary.
+func (d *dictReader) child(key, elem string, value any) *dictReader {
+	dict, ok := value.(map[string]any)
+	if !ok {
+		d.typeError(key+"/"+elem, "dictionary", value)
+		return nil
+	}
+	return newDictReader(d.path+"/"+key+"/"+elem, dict)
+}
+
+// finish returns the remaining keys and the recorded keys, and passes
+// a recorded error on to the parent reader.
+func (d *dictReader) finish(parent *dictReader) (map[string]any, keys) {
+	if parent != nil && parent.err == nil {
+		parent.err = d.err
+	}
+	return d.rest, d.keys
+}
+
+func (d *dictReader) any(key string) any {
+	v, ok := d.rest[key]
+	if !ok {
+		return nil
+	}
+	d.keys.present[key] = true
+	if v == nil {
+		if d.keys.nulls == nil {
+			d.keys.nulls = make(map[string]bool)
+		}
+		d.keys.nulls[key] = true
+	}
+	delete(d.rest, key)
+	return v
+}
+
+func (d *dictReader) string(key string) string {
+	v := d.any(key)
+	if v == nil {
+		return ""
+	}
+	s, ok := v.(string)
+	if !ok {
+		d.typeError(key, "string", v)
+	}
+	return s
+}
+
+func (d *dictReader) int(key string) int {
+	v := d.any(key)
+	if v == nil {
+		return 0
+	}
+	i, ok := v.(int)
+	if !ok {
+		d.typeError(key, "integer", v)
+	}
+	return i
+}
+
+func (d *dictReader) number(key string) float64 {
+	switch v := d.any(key).(type) {
+	case nil:
+		return 0
+	case int:
+		if d.keys.ints == nil {
+			d.keys.ints = make(map[string]bool)
+		}
+		d.keys.ints[key] = true
+		return float64(v)
+	case float64:
+		return v
+	default:
+		d.typeError(key, "number", v)
+		return 0
+	}
+}
+
+func (d *dictReader) dict(key string) (map[string]any, bool) {
+	v := d.any(key)
+	if v == nil {
+		return nil, false
+	}
+	dict, ok := v.(map[string]any)
+	if !ok {
+		d.typeError(key, "dictionary", v)
+	}
+	return dict, ok
+}
+
+func (d *dictReader) array(key string) []any {
+	v := d.any(key)
+	if v == nil {
+		return nil
+	}
+	array, ok := v.([]any)
+	if !ok {
+		d.typeError(key, "array", v)
+	}
+	return array
+}
+
+func (d *dictReader) typeError(key, want string, got any) {
+	if d.err == nil {
+		d.err = fmt.Errorf("savegame key %q: expected %s, got %T", d.path+"/"+key, want, got)
+	}
+}
+
+// dictWriter sets the values of modelled keys in a copy of the
+// remaining keys.
+type dictWriter struct {
+	dict map[string]any
+	keys keys
+}
+
+func newDictWriter(rest map[string]any, k keys) dictWriter {
+	return dictWriter{dict: copyDict(rest), keys: k}
+}
+
+// copyDict returns a copy of the top level of a dictionary.
+func copyDict(dict map[string]any) map[string]any {
+	c := make(map[string]any, len(dict))
+	for k, v := range dict {
+		c[k] = v
+	}
+	return c
+}
+
+// set writes a value that is not zero. A zero value is only written if
+// the key was present, as null if it was null.
+func (w dictWriter) set(key string, v any, zero bool) {
+	switch {
+	case !zero:
+		w.dict[key] = v
+	case w.keys.nulls[key]:
+		w.dict[key] = nil
+	case w.keys.present[key]:
+		w.dict[key] = v
+	}
+}
+
+// setNil writes null if the key was null.
+func (w dictWriter) setNil(key string) {
+	w.set(key, nil, true)
+}
+
+func (w dictWriter) setString(key, v string) {
+	w.set(key, v, v == "")
+}
+
+func (w dictWriter) setInt(key string, v int) {
+	w.set(key, v, v == 0)
+}
+
+// setNumber writes an integer if the number was an integer before and
+// still has an integral value, otherwise a float.
+func (w dictWriter) setNumber(key string, v float64) {
+	if w.keys.ints[key] && v == math.Trunc(v) {
+		w.set(key, int(v), v == 0)
+		return
+	}
+	w.set(key, v, v == 0)
+}
+
+func (w dictWriter) setArray(key string, v []any) {
+	w.set(key, v, len(v) == 0)
+}
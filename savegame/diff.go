@@ -0,0 +1,123 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package savegame
+
+import (
+	"github.com/fzipp/gg/ggdict"
+)
+
+// Diff returns the changes that turn savegame dictionary a into savegame
+// dictionary b. It is the same as ggdict.Diff, except that the objects of
+// an inventory slot are compared as a collection: an object that was
+// picked up or given away is reported as a single added or removed value
+// at its index in the new or old inventory, respectively, instead of as
+// modifications of all following inventory positions.
+func Diff(a, b map[string]any) []ggdict.Change {
+	invA, okA := a["inventory"].(map[string]any)
+	invB, okB := b["inventory"].(map[string]any)
+	if !okA || !okB {
+		return ggdict.Diff(a, b)
+	}
+
+	a, b = withoutKey(a, "inventory"), withoutKey(b, "inventory")
+	invA, invB, objectChanges := diffInventoryObjects(invA, invB)
+	invChanges := ggdict.Diff(
+		map[string]any{"inventory": invA},
+		map[string]any{"inventory": invB},
+	)
+	invChanges = append(invChanges, objectChanges...)
+
+	// Insert the inventory changes at the position of the inventory key
+	// to keep the changes ordered by path.
+	changes := ggdict.Diff(a, b)
+	i := 0
+	for i < len(changes) && changes[i].Path[0].(string) < "inventory" {
+		i++
+	}
+	return append(changes[:i], append(invChanges, changes[i:]...)...)
+}
+
+// diffInventoryObjects compares the object lists of the inventory slots
+// present in both inventories. It returns copies of the inventories
+// without these object lists and the changes between them.
+func diffInventoryObjects(a, b map[string]any) (map[string]any, map[string]any, []ggdict.Change) {
+	slotsA, okA := a["slots"].([]any)
+	slotsB, okB := b["slots"].([]any)
+	if !okA || !okB {
+		return a, b, nil
+	}
+	slotsA = append([]any(nil), slotsA...)
+	slotsB = append([]any(nil), slotsB...)
+	var changes []ggdict.Change
+	for i := 0; i < len(slotsA) && i < len(slotsB); i++ {
+		slotA, okA := slotsA[i].(map[string]any)
+		slotB, okB := slotsB[i].(map[string]any)
+		if !okA || !okB {
+			continue
+		}
+		objectsA, okA := slotA["objects"].([]any)
+		objectsB, okB := slotB["objects"].([]any)
+		if !okA || !okB {
+			continue
+		}
+		p := ggdict.Path{"inventory", "slots", i, "objects"}
+		changes = append(changes, diffCollections(p, objectsA, objectsB)...)
+		slotsA[i] = withoutKey(slotA, "objects")
+		slotsB[i] = withoutKey(slotB, "objects")
+	}
+	a, b = withoutKey(a, "slots"), withoutKey(b, "slots")
+	a["slots"], b["slots"] = slotsA, slotsB
+	return a, b, changes
+}
+
+// diffCollections compares the arrays a and b regardless of the order of
+// their elements. Each element of a without an equal element in b is
+// reported as removed, each element of b without an equal element in a
+// as added.
+func diffCollections(p ggdict.Path, a, b []any) []ggdict.Change {
+	var changes []ggdict.Change
+	matched := make([]bool, len(b))
+	for i, x := range a {
+		found := false
+		for j, y := range b {
+			if !matched[j] && equalValues(x, y) {
+				matched[j], found = true, true
+				break
+			}
+		}
+		if !found {
+			changes = append(changes, ggdict.Change{Kind: ggdict.Removed, Path: appendPath(p, i), Old: x})
+		}
+	}
+	for j, y := range b {
+		if !matched[j] {
+			changes = append(changes, ggdict.Change{Kind: ggdict.Added, Path: appendPath(p, j), New: y})
+		}
+	}
+	return changes
+}
+
+func equalValues(x, y any) bool {
+	return len(ggdict.Diff(map[string]any{"v": x}, map[string]any{"v": y})) == 0
+}
+
+// withoutKey returns a shallow copy of the dictionary without the key.
+func withoutKey(dict map[string]any, key string) map[string]any {
+	c := make(map[string]any, len(dict))
+	for k, v := range dict {
+		if k != key {
+			c[k] = v
+		}
+	}
+	return c
+}
+
+// appendPath returns a new path with elem appended to p,
+// without modifying the underlying array of p.
+func appendPath(p ggdict.Path, elem any) ggdict.Path {
+	q := make(ggdict.Path, len(p), len(p)+1)
+	copy(q, p)
+	return append(q, elem)
+}
@@ -0,0 +1,43 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package savegame
+
+import (
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	a := testSavegameDict()
+	b := testSavegameDict()
+	b["gameTime"] = 4800
+	b["globals"].(map[string]any)["g.act"] = 3
+	b["actors"].(map[string]any)["ray"].(map[string]any)["_room"] = "Diner"
+	b["objects"].(map[string]any)["mainStreetPhone"].(map[string]any)["_state"] = 0
+	b["inventory"].(map[string]any)["slots"] = []any{
+		map[string]any{"objects": []any{"notebook", "coin"}, "scroll": 1},
+		map[string]any{"objects": []any{}},
+	}
+	b["rooms"].(map[string]any)["Diner"] = map[string]any{}
+
+	want := []string{
+		`~ actors.ray._room: "MainStreet" -> "Diner"`,
+		`~ gameTime: 4711 -> 4800`,
+		`~ globals["g.act"]: 2 -> 3`,
+		`~ inventory.slots[0].scroll: 0 -> 1`,
+		`- inventory.slots[0].objects[0]: "badge"`,
+		`+ inventory.slots[0].objects[1]: "coin"`,
+		`~ objects.mainStreetPhone._state: 1 -> 0`,
+		`+ rooms.Diner: {}`,
+	}
+	changes := Diff(a, b)
+	if len(changes) != len(want) {
+		t.Errorf("number of changes was %d, want: %d", len(changes), len(want))
+	}
+	for i := 0; i < len(changes) && i < len(want); i++ {
+		if got := changes[i].String(); got != want[i] {
+			t.Errorf("change %d was %s, want: %s", i, got, want[i])
+		}
+	}
+}
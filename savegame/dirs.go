@@ -0,0 +1,73 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package savegame
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SteamAppID is the Steam application ID of Thimbleweed Park.
+const SteamAppID = "569860"
+
+// DefaultDirs returns the standard Linux directories in which
+// Thimbleweed Park savegames are stored, in the order in which FindDir
+// searches them:
+//
+//	$XDG_DATA_HOME/Terrible Toybox/Thimbleweed Park
+//	~/.local/share/Terrible Toybox/Thimbleweed Park
+//	~/.steam/steam/userdata/*/569860/remote
+//	~/.local/share/Steam/userdata/*/569860/remote
+//
+// The last two are the Steam Cloud directories, with one directory per
+// Steam user. They are only included if they exist, since they are found
+// by expanding the wildcard for the user. The first two are included
+// even if they do not exist.
+func DefaultDirs() []string {
+	var dirs []string
+	home, _ := os.UserHomeDir()
+	if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" {
+		dirs = append(dirs, filepath.Join(xdg, "Terrible Toybox", "Thimbleweed Park"))
+	}
+	if home == "" {
+		return dirs
+	}
+	dirs = append(dirs, filepath.Join(home, ".local", "share", "Terrible Toybox", "Thimbleweed Park"))
+	for _, steam := range []string{
+		filepath.Join(home, ".steam", "steam"),
+		filepath.Join(home, ".local", "share", "Steam"),
+	} {
+		matches, _ := filepath.Glob(filepath.Join(steam, "userdata", "*", SteamAppID, "remote"))
+		dirs = append(dirs, matches...)
+	}
+	return dirs
+}
+
+// FindDir returns the first of the DefaultDirs that contains savegame
+// files.
+func FindDir() (string, error) {
+	dirs := DefaultDirs()
+	for _, dir := range dirs {
+		if hasSavegames(dir) {
+			return dir, nil
+		}
+	}
+	return "", errors.New("no save directory found in " + strings.Join(dirs, ", "))
+}
+
+func hasSavegames(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		if !e.IsDir() && isSavegameFile(e.Name()) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package savegame
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, "data"))
+
+	if dir, err := FindDir(); err == nil {
+		t.Errorf("found save directory %q in empty home directory, want: error", dir)
+	}
+
+	steamDir := filepath.Join(home, ".steam", "steam", "userdata", "1234", SteamAppID, "remote")
+	xdgDir := filepath.Join(home, "data", "Terrible Toybox", "Thimbleweed Park")
+	for _, dir := range []string{steamDir, xdgDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := os.WriteFile(filepath.Join(steamDir, "Savegame1.save"), nil, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		addSave string
+		want    string
+	}{
+		{"", steamDir},
+		{xdgDir, xdgDir},
+	}
+	for _, tt := range tests {
+		if tt.addSave != "" {
+			err := os.WriteFile(filepath.Join(tt.addSave, "Savegame2.save"), nil, 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		dir, err := FindDir()
+		if err != nil {
+			t.Errorf("finding save directory failed: %v", err)
+			continue
+		}
+		if dir != tt.want {
+			t.Errorf("found save directory was %q, want: %q", dir, tt.want)
+		}
+	}
+}
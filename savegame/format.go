@@ -0,0 +1,170 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package savegame
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/fzipp/gg/crypt/xxtea"
+	"github.com/fzipp/gg/ggdict"
+)
+
+// Format describes the savegame file format of a game. A savegame file
+// contains a GGDictionary, padded with zeros to a minimum size and followed
+// by a footer, which begins with a checksum as little-endian uint32.
+// The data and the footer are encrypted with XXTEA as a single block.
+type Format struct {
+	// Name is the name of the format, e.g. "thimbleweed".
+	Name string
+	// Key is the XXTEA key.
+	Key xxtea.Key
+	// DictFormat is the format of the GGDictionary.
+	DictFormat ggdict.Format
+	// MinSize is the size to which the GGDictionary data is padded.
+	MinSize int
+	// FooterSize is the size of the footer.
+	FooterSize int
+	// ChecksumSeed is the initial value of the checksum, to which the
+	// values of all bytes of the padded data are added.
+	ChecksumSeed uint32
+}
+
+// FormatThimbleweed is the savegame format of Thimbleweed Park.
+var FormatThimbleweed = Format{
+	Name:         "thimbleweed",
+	Key:          XXTEAKey,
+	DictFormat:   ggdict.FormatThimbleweed,
+	MinSize:      500_000,
+	FooterSize:   16,
+	ChecksumSeed: 0x6583463,
+}
+
+// Formats are the known savegame formats, in the order in which Read
+// tries them.
+var Formats = []Format{
+	FormatThimbleweed,
+	// TODO: Return to Monkey Island and Delores savegames. Their XXTEA
+	// keys, padding sizes and footer layouts are not known yet.
+}
+
+// FormatByName returns the known format with the given name.
+func FormatByName(name string) (Format, bool) {
+	for _, f := range Formats {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Format{}, false
+}
+
+// Load reads a savegame file in this format.
+func (f Format) Load(path string) (map[string]any, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open savegame file: %w", err)
+	}
+	defer file.Close()
+	return f.Read(file)
+}
+
+// Read reads savegame data in this format.
+func (f Format) Read(r io.Reader) (map[string]any, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not read savegame data: %w", err)
+	}
+	return f.decode(data)
+}
+
+// decode decrypts the data in place and decodes the dictionary.
+func (f Format) decode(data []byte) (map[string]any, error) {
+	err := f.decrypt(data)
+	if err != nil {
+		return nil, err
+	}
+	dict, err := ggdict.Unmarshal(data, f.DictFormat)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal savegame data: %w", err)
+	}
+	return dict, nil
+}
+
+// decrypt decrypts the data in place and validates the checksum.
+func (f Format) decrypt(data []byte) error {
+	if len(data) < f.FooterSize {
+		return fmt.Errorf("savegame data too short: %d bytes", len(data))
+	}
+	xxtea.DecryptInPlace(data, f.Key)
+	if stored, computed := f.checksums(data); stored != computed {
+		return &ChecksumError{Stored: stored, Computed: computed}
+	}
+	return nil
+}
+
+// Save writes a savegame file in this format.
+func (f Format) Save(path string, dict map[string]any) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not open savegame file: %w", err)
+	}
+	defer file.Close()
+	return f.Write(file, dict)
+}
+
+// Write writes savegame data in this format.
+//
+// Since the data is encrypted as a single XXTEA block, it cannot be
+// streamed. Instead, the buffer for the padded data is reused between
+// calls, so that writing many savegames does not allocate a new buffer
+// of the padded size for each of them.
+func (f Format) Write(w io.Writer, dict map[string]any) error {
+	bufp := writeBuffers.Get().(*[]byte)
+	defer writeBuffers.Put(bufp)
+	if size := f.MinSize + f.FooterSize; cap(*bufp) < size {
+		*bufp = make([]byte, 0, size)
+	}
+	data, err := ggdict.MarshalOptions{}.AppendMarshal((*bufp)[:0], dict, f.DictFormat)
+	if err != nil {
+		return fmt.Errorf("could not marshal savegame data: %w", err)
+	}
+	data = zeroPadWithFooter(data, f.MinSize, f.FooterSize)
+	*bufp = data
+	checksumIndex := len(data) - f.FooterSize
+	endianness.PutUint32(data[checksumIndex:], f.checksum(data[:checksumIndex]))
+	xxtea.EncryptInPlace(data, f.Key)
+	_, err = w.Write(data)
+	if err != nil {
+		return fmt.Errorf("could not write savegame data: %w", err)
+	}
+	return nil
+}
+
+// writeBuffers are the buffers reused by Write.
+var writeBuffers = sync.Pool{
+	New: func() any { return new([]byte) },
+}
+
+func (f Format) isChecksumOk(data []byte) bool {
+	stored, computed := f.checksums(data)
+	return stored == computed
+}
+
+// checksums returns the checksum stored in the footer of decrypted data
+// and the checksum computed from the data before the footer.
+func (f Format) checksums(data []byte) (stored, computed uint32) {
+	checksumIndex := len(data) - f.FooterSize
+	return endianness.Uint32(data[checksumIndex:]), f.checksum(data[:checksumIndex])
+}
+
+func (f Format) checksum(data []byte) uint32 {
+	sum := f.ChecksumSeed
+	for _, b := range data {
+		sum += uint32(b)
+	}
+	return sum
+}
@@ -0,0 +1,94 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package savegame
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/fzipp/gg/crypt/xxtea"
+	"github.com/fzipp/gg/ggdict"
+)
+
+func TestDecode(t *testing.T) {
+	// Only the Thimbleweed Park format is known, so the detection among
+	// several formats is tested with an additional format that does not
+	// belong to any game.
+	testFormat := Format{
+		Name:         "test",
+		Key:          xxtea.Key{1, 2, 3, 4},
+		DictFormat:   ggdict.FormatMonkey,
+		MinSize:      1000,
+		FooterSize:   8,
+		ChecksumSeed: 42,
+	}
+	defer func(formats []Format) { Formats = formats }(Formats)
+	Formats = append(Formats, testFormat)
+
+	dict := map[string]any{
+		"name":  "Delores",
+		"level": 3,
+		"items": []any{"pen", "notebook"},
+	}
+	tests := []struct {
+		format Format
+	}{
+		{FormatThimbleweed},
+		{testFormat},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		err := tt.format.Write(&buf, dict)
+		if err != nil {
+			t.Errorf("writing savegame in format %q failed: %v", tt.format.Name, err)
+			continue
+		}
+		data := buf.Bytes()
+		if len(data) != tt.format.MinSize+tt.format.FooterSize {
+			t.Errorf("length of savegame in format %q was %d, want: %d",
+				tt.format.Name, len(data), tt.format.MinSize+tt.format.FooterSize)
+		}
+		orig := append([]byte(nil), data...)
+		got, format, err := Decode(data)
+		if err != nil {
+			t.Errorf("decoding savegame in format %q failed: %v", tt.format.Name, err)
+			continue
+		}
+		if !bytes.Equal(data, orig) {
+			t.Errorf("decoding savegame in format %q modified the data", tt.format.Name)
+		}
+		if format.Name != tt.format.Name {
+			t.Errorf("detected format was %q, want: %q", format.Name, tt.format.Name)
+		}
+		if !reflect.DeepEqual(got, dict) {
+			t.Errorf("decoded savegame in format %q was %v, want: %v", tt.format.Name, got, dict)
+		}
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, map[string]any{"a": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	data[100] ^= 0xFF
+	_, _, err = Decode(data)
+	if err == nil {
+		t.Errorf("decoding corrupted savegame: expected error, got none")
+	}
+}
+
+func TestFormatByName(t *testing.T) {
+	f, ok := FormatByName("thimbleweed")
+	if !ok || f.Name != FormatThimbleweed.Name {
+		t.Errorf("format by name %q was %q (%v), want: %q", "thimbleweed", f.Name, ok, FormatThimbleweed.Name)
+	}
+	if _, ok := FormatByName("unknown"); ok {
+		t.Errorf("format by name %q was found, want: not found", "unknown")
+	}
+}
@@ -0,0 +1,382 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package savegame
+
+import (
+	"fmt"
+	"io"
+)
+
+// Game is the typed model of a savegame dictionary.
+//
+// Keys of the dictionary that are not modelled by a field are kept in the
+// Extra and Vars maps, so that converting a dictionary to a Game and back
+// with GameFromDict and Dict yields the same dictionary. Optional fields
+// with a zero value are omitted from the dictionary, unless they were
+// present in the original dictionary. Keys that were null are written as
+// null as long as their field has a zero value.
+type Game struct {
+	// Version is the savegame version ("version").
+	Version int
+	// SaveBuild is the build number of the game that wrote the savegame
+	// ("savebuild").
+	SaveBuild int
+	// SaveTime is the time the game was saved as Unix time ("savetime").
+	SaveTime int
+	// GameTime is the played time in seconds ("gameTime").
+	GameTime float64
+	// GameGUID identifies a playthrough ("gameGUID").
+	GameGUID string
+	// CurrentRoom is the name of the current room ("currentRoom").
+	CurrentRoom string
+	// SelectedActor is the name of the selected actor ("selectedActor").
+	SelectedActor string
+	// EasyMode is 1 if the game is played in casual mode ("easy_mode").
+	EasyMode int
+	// InputState is the state of the user input ("inputState").
+	InputState int
+
+	// Actors are the actors by name ("actors").
+	Actors map[string]*Actor
+	// Objects are the objects by name ("objects").
+	Objects map[string]*Object
+	// Rooms are the rooms by name ("rooms").
+	Rooms map[string]*Room
+	// Inventory contains the inventories of the playable actors
+	// ("inventory").
+	Inventory *Inventory
+	// Globals are the global script variables ("globals").
+	Globals map[string]any
+	// Callbacks are the pending timed script callbacks ("callbacks").
+	Callbacks *Callbacks
+	// Dialog is the dialog state ("dialog"). It maps dialog state keys
+	// to values, usually integers.
+	Dialog map[string]any
+
+	// Extra contains the top-level keys not modelled by a field.
+	Extra map[string]any
+
+	keys keys
+}
+
+// Actor is the saved state of an actor.
+type Actor struct {
+	// Room is the name of the room the actor is in ("_room").
+	Room string
+	// Costume is the name of the actor's costume ("_costume").
+	Costume string
+	// Vars are the actor's script variables and other keys not
+	// modelled by a field.
+	Vars map[string]any
+
+	keys keys
+}
+
+// Object is the saved state of an object.
+type Object struct {
+	// State is the object's state ("_state").
+	State int
+	// Touchable is 1 if the object can be interacted with ("_touchable").
+	Touchable int
+	// Vars are the object's script variables and other keys not
+	// modelled by a field.
+	Vars map[string]any
+
+	keys keys
+}
+
+// Room is the saved state of a room.
+type Room struct {
+	// Vars are the room's script variables.
+	Vars map[string]any
+}
+
+// Inventory is the saved state of the inventories.
+type Inventory struct {
+	// Slots are the inventories of the playable actors ("slots").
+	Slots []*InventorySlot
+	// Extra contains the keys not modelled by a field.
+	Extra map[string]any
+
+	keys keys
+}
+
+// InventorySlot is the inventory of a playable actor.
+type InventorySlot struct {
+	// Objects are the names of the objects in the inventory ("objects").
+	Objects []string
+	// Extra contains the keys not modelled by a field.
+	Extra map[string]any
+
+	keys keys
+}
+
+// Callbacks is the saved state of the timed script callbacks.
+type Callbacks struct {
+	// Callbacks are the pending callbacks ("callbacks").
+	Callbacks []*Callback
+	// NextGUID is the ID of the next callback ("nextGuid").
+	NextGUID int
+	// Extra contains the keys not modelled by a field.
+	Extra map[string]any
+
+	keys keys
+}
+
+// Callback is a pending timed script callback.
+type Callback struct {
+	// GUID is the ID of the callback ("guid").
+	GUID int
+	// Function is the name of the script function to call ("function").
+	Function string
+	// Time is the remaining time in seconds ("time").
+	Time float64
+	// Param is the parameter passed to the function ("param").
+	Param any
+	// Extra contains the keys not modelled by a field.
+	Extra map[string]any
+
+	keys keys
+}
+
+// LoadGame reads a savegame file as a Game. The format is detected,
+// see Read.
+func LoadGame(path string) (*Game, error) {
+	dict, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return GameFromDict(dict)
+}
+
+// ReadGame reads savegame data as a Game. The format is detected,
+// see Read.
+func ReadGame(r io.Reader) (*Game, error) {
+	dict, err := Read(r)
+	if err != nil {
+		return nil, err
+	}
+	return GameFromDict(dict)
+}
+
+// GameFromDict converts a savegame dictionary as returned by Load or Read
+// to a Game. It returns an error if a modelled key has a value of an
+// unexpected type.
+func GameFromDict(dict map[string]any) (*Game, error) {
+	d := newDictReader("", dict)
+	g := &Game{}
+	g.Version = d.int("version")
+	g.SaveBuild = d.int("savebuild")
+	g.SaveTime = d.int("savetime")
+	g.GameTime = d.number("gameTime")
+	g.GameGUID = d.string("gameGUID")
+	g.CurrentRoom = d.string("currentRoom")
+	g.SelectedActor = d.string("selectedActor")
+	g.EasyMode = d.int("easy_mode")
+	g.InputState = d.int("inputState")
+	if actors, ok := d.dict("actors"); ok {
+		g.Actors = make(map[string]*Actor, len(actors))
+		for name := range actors {
+			a := d.child("actors", name, actors[name])
+			if a == nil {
+				continue
+			}
+			g.Actors[name] = &Actor{
+				Room:    a.string("_room"),
+				Costume: a.string("_costume"),
+			}
+			g.Actors[name].Vars, g.Actors[name].keys = a.finish(d)
+		}
+	}
+	if objects, ok := d.dict("objects"); ok {
+		g.Objects = make(map[string]*Object, len(objects))
+		for name := range objects {
+			o := d.child("objects", name, objects[name])
+			if o == nil {
+				continue
+			}
+			g.Objects[name] = &Object{
+				State:     o.int("_state"),
+				Touchable: o.int("_touchable"),
+			}
+			g.Objects[name].Vars, g.Objects[name].keys = o.finish(d)
+		}
+	}
+	if rooms, ok := d.dict("rooms"); ok {
+		g.Rooms = make(map[string]*Room, len(rooms))
+		for name := range rooms {
+			r := d.child("rooms", name, rooms[name])
+			if r == nil {
+				continue
+			}
+			g.Rooms[name] = &Room{}
+			g.Rooms[name].Vars, _ = r.finish(d)
+		}
+	}
+	if inventory, ok := d.dict("inventory"); ok {
+		g.Inventory = inventoryFromDict(d, inventory)
+	}
+	if globals, ok := d.dict("globals"); ok {
+		g.Globals = copyDict(globals)
+	}
+	if callbacks, ok := d.dict("callbacks"); ok {
+		g.Callbacks = callbacksFromDict(d, callbacks)
+	}
+	if dialog, ok := d.dict("dialog"); ok {
+		g.Dialog = copyDict(dialog)
+	}
+	g.Extra, g.keys = d.finish(nil)
+	if d.err != nil {
+		return nil, d.err
+	}
+	return g, nil
+}
+
+func inventoryFromDict(parent *dictReader, dict map[string]any) *Inventory {
+	d := parent.sub("inventory", dict)
+	inv := &Inventory{}
+	for i, slot := range d.array("slots") {
+		s := d.child("slots", fmt.Sprint(i), slot)
+		if s == nil {
+			continue
+		}
+		invSlot := &InventorySlot{}
+		for j, object := range s.array("objects") {
+			name, ok := object.(string)
+			if !ok {
+				s.typeError(fmt.Sprintf("objects/%d", j), "string", object)
+				continue
+			}
+			invSlot.Objects = append(invSlot.Objects, name)
+		}
+		invSlot.Extra, invSlot.keys = s.finish(d)
+		inv.Slots = append(inv.Slots, invSlot)
+	}
+	inv.Extra, inv.keys = d.finish(parent)
+	return inv
+}
+
+func callbacksFromDict(parent *dictReader, dict map[string]any) *Callbacks {
+	d := parent.sub("callbacks", dict)
+	cbs := &Callbacks{}
+	for i, callback := range d.array("callbacks") {
+		c := d.child("callbacks", fmt.Sprint(i), callback)
+		if c == nil {
+			continue
+		}
+		cb := &Callback{
+			GUID:     c.int("guid"),
+			Function: c.string("function"),
+			Time:     c.number("time"),
+			Param:    c.any("param"),
+		}
+		cb.Extra, cb.keys = c.finish(d)
+		cbs.Callbacks = append(cbs.Callbacks, cb)
+	}
+	cbs.NextGUID = d.int("nextGuid")
+	cbs.Extra, cbs.keys = d.finish(parent)
+	return cbs
+}
+
+// Dict converts the Game to a savegame dictionary, which can be written
+// with Save or Write.
+func (g *Game) Dict() map[string]any {
+	w := newDictWriter(g.Extra, g.keys)
+	w.setInt("version", g.Version)
+	w.setInt("savebuild", g.SaveBuild)
+	w.setInt("savetime", g.SaveTime)
+	w.setNumber("gameTime", g.GameTime)
+	w.setString("gameGUID", g.GameGUID)
+	w.setString("currentRoom", g.CurrentRoom)
+	w.setString("selectedActor", g.SelectedActor)
+	w.setInt("easy_mode", g.EasyMode)
+	w.setInt("inputState", g.InputState)
+	if g.Actors != nil {
+		actors := make(map[string]any, len(g.Actors))
+		for name, a := range g.Actors {
+			aw := newDictWriter(a.Vars, a.keys)
+			aw.setString("_room", a.Room)
+			aw.setString("_costume", a.Costume)
+			actors[name] = aw.dict
+		}
+		w.dict["actors"] = actors
+	} else {
+		w.setNil("actors")
+	}
+	if g.Objects != nil {
+		objects := make(map[string]any, len(g.Objects))
+		for name, o := range g.Objects {
+			ow := newDictWriter(o.Vars, o.keys)
+			ow.setInt("_state", o.State)
+			ow.setInt("_touchable", o.Touchable)
+			objects[name] = ow.dict
+		}
+		w.dict["objects"] = objects
+	} else {
+		w.setNil("objects")
+	}
+	if g.Rooms != nil {
+		rooms := make(map[string]any, len(g.Rooms))
+		for name, r := range g.Rooms {
+			rooms[name] = newDictWriter(r.Vars, keys{}).dict
+		}
+		w.dict["rooms"] = rooms
+	} else {
+		w.setNil("rooms")
+	}
+	if g.Inventory != nil {
+		w.dict["inventory"] = g.Inventory.dict()
+	} else {
+		w.setNil("inventory")
+	}
+	if g.Globals != nil {
+		w.dict["globals"] = copyDict(g.Globals)
+	} else {
+		w.setNil("globals")
+	}
+	if g.Callbacks != nil {
+		w.dict["callbacks"] = g.Callbacks.dict()
+	} else {
+		w.setNil("callbacks")
+	}
+	if g.Dialog != nil {
+		w.dict["dialog"] = copyDict(g.Dialog)
+	} else {
+		w.setNil("dialog")
+	}
+	return w.dict
+}
+
+func (inv *Inventory) dict() map[string]any {
+	w := newDictWriter(inv.Extra, inv.keys)
+	slots := make([]any, len(inv.Slots))
+	for i, slot := range inv.Slots {
+		sw := newDictWriter(slot.Extra, slot.keys)
+		objects := make([]any, len(slot.Objects))
+		for j, name := range slot.Objects {
+			objects[j] = name
+		}
+		sw.setArray("objects", objects)
+		slots[i] = sw.dict
+	}
+	w.setArray("slots", slots)
+	return w.dict
+}
+
+func (cbs *Callbacks) dict() map[string]any {
+	w := newDictWriter(cbs.Extra, cbs.keys)
+	callbacks := make([]any, len(cbs.Callbacks))
+	for i, cb := range cbs.Callbacks {
+		cw := newDictWriter(cb.Extra, cb.keys)
+		cw.setInt("guid", cb.GUID)
+		cw.setString("function", cb.Function)
+		cw.setNumber("time", cb.Time)
+		cw.set("param", cb.Param, cb.Param == nil)
+		callbacks[i] = cw.dict
+	}
+	w.setArray("callbacks", callbacks)
+	w.setInt("nextGuid", cbs.NextGUID)
+	return w.dict
+}
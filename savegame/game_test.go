@@ -0,0 +1,250 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package savegame
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func testSavegameDict() map[string]any {
+	return map[string]any{
+		"version":       2,
+		"savebuild":     958,
+		"savetime":      1603116000,
+		"gameTime":      4711,
+		"gameGUID":      "6E8A4D2C",
+		"currentRoom":   "MainStreet",
+		"selectedActor": "ray",
+		"easy_mode":     0,
+		"actors": map[string]any{
+			"ray": map[string]any{
+				"_room":    "MainStreet",
+				"_costume": "RayAnimation",
+				"_pos":     "{120,40}",
+				"talkedTo": 1,
+			},
+			"reyes": map[string]any{},
+		},
+		"objects": map[string]any{
+			"mainStreetPhone": map[string]any{
+				"_state":     1,
+				"_touchable": 0,
+				"ringing":    1.5,
+			},
+		},
+		"rooms": map[string]any{
+			"MainStreet": map[string]any{"visited": 1},
+		},
+		"inventory": map[string]any{
+			"slots": []any{
+				map[string]any{"objects": []any{"badge", "notebook"}, "scroll": 0},
+				map[string]any{"objects": []any{}},
+			},
+		},
+		"globals": map[string]any{"g.act": 2},
+		"callbacks": map[string]any{
+			"callbacks": []any{
+				map[string]any{"guid": 3, "function": "startTrain", "time": 12.5, "param": nil},
+				map[string]any{"guid": 4, "function": "hoot", "time": 3},
+			},
+			"nextGuid": 5,
+		},
+		"dialog":         map[string]any{"&ray.talkedTo": 1},
+		"unknownSection": []any{1, 2.5, "three"},
+	}
+}
+
+func TestGameRoundTrip(t *testing.T) {
+	dict := testSavegameDict()
+	g, err := GameFromDict(dict)
+	if err != nil {
+		t.Fatalf("converting dictionary to game failed: %v", err)
+	}
+	if got := g.Dict(); !reflect.DeepEqual(got, dict) {
+		t.Errorf("round-tripped dictionary was\n%v\nwant:\n%v", got, dict)
+	}
+
+	var buf bytes.Buffer
+	err = Write(&buf, g.Dict())
+	if err != nil {
+		t.Fatalf("writing savegame failed: %v", err)
+	}
+	g2, err := ReadGame(&buf)
+	if err != nil {
+		t.Fatalf("reading savegame failed: %v", err)
+	}
+	if got := g2.Dict(); !reflect.DeepEqual(got, dict) {
+		t.Errorf("dictionary read from savegame was\n%v\nwant:\n%v", got, dict)
+	}
+}
+
+func TestGameNullRoundTrip(t *testing.T) {
+	tests := []map[string]any{
+		{"version": nil},
+		{"gameTime": nil},
+		{"currentRoom": nil},
+		{"globals": nil},
+		{"dialog": nil},
+		{"actors": nil, "objects": nil, "rooms": nil},
+		{"actors": map[string]any{"ray": map[string]any{"_room": nil, "_costume": "RayAnimation"}}},
+		{"objects": map[string]any{"phone": map[string]any{"_state": nil}}},
+		{"inventory": nil},
+		{"inventory": map[string]any{"slots": nil}},
+		{"inventory": map[string]any{"slots": []any{map[string]any{"objects": nil}}}},
+		{"callbacks": nil},
+		{"callbacks": map[string]any{"callbacks": nil, "nextGuid": nil}},
+		{"callbacks": map[string]any{"callbacks": []any{map[string]any{"guid": nil, "time": nil, "param": nil}}}},
+	}
+	for _, dict := range tests {
+		g, err := GameFromDict(dict)
+		if err != nil {
+			t.Errorf("converting %v to game failed: %v", dict, err)
+			continue
+		}
+		if got := g.Dict(); !reflect.DeepEqual(got, dict) {
+			t.Errorf("round-tripped dictionary was %#v, want: %#v", got, dict)
+		}
+	}
+}
+
+func TestGameNullModified(t *testing.T) {
+	g, err := GameFromDict(map[string]any{
+		"version":  nil,
+		"gameTime": nil,
+		"globals":  nil,
+		"actors":   map[string]any{"ray": map[string]any{"_room": nil}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Version = 2
+	g.GameTime = 1.5
+	g.Globals = map[string]any{"g.act": 2}
+	g.Actors["ray"].Room = "Diner"
+	want := map[string]any{
+		"version":  2,
+		"gameTime": 1.5,
+		"globals":  map[string]any{"g.act": 2},
+		"actors":   map[string]any{"ray": map[string]any{"_room": "Diner"}},
+	}
+	if got := g.Dict(); !reflect.DeepEqual(got, want) {
+		t.Errorf("modified dictionary was %#v, want: %#v", got, want)
+	}
+}
+
+func TestGameFields(t *testing.T) {
+	g, err := GameFromDict(testSavegameDict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"Version", g.Version, 2},
+		{"GameTime", g.GameTime, 4711.0},
+		{"CurrentRoom", g.CurrentRoom, "MainStreet"},
+		{"Actors[ray].Costume", g.Actors["ray"].Costume, "RayAnimation"},
+		{"Actors[ray].Vars", g.Actors["ray"].Vars, map[string]any{"_pos": "{120,40}", "talkedTo": 1}},
+		{"Objects[mainStreetPhone].State", g.Objects["mainStreetPhone"].State, 1},
+		{"Rooms[MainStreet].Vars", g.Rooms["MainStreet"].Vars, map[string]any{"visited": 1}},
+		{"Inventory.Slots[0].Objects", g.Inventory.Slots[0].Objects, []string{"badge", "notebook"}},
+		{"Inventory.Slots[0].Extra", g.Inventory.Slots[0].Extra, map[string]any{"scroll": 0}},
+		{"Callbacks.Callbacks[1].Time", g.Callbacks.Callbacks[1].Time, 3.0},
+		{"Callbacks.NextGUID", g.Callbacks.NextGUID, 5},
+		{"Extra", g.Extra, map[string]any{"unknownSection": []any{1, 2.5, "three"}}},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s was %#v, want: %#v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestGameDictModified(t *testing.T) {
+	g, err := GameFromDict(testSavegameDict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.EasyMode = 1
+	g.GameTime = 4711.5
+	g.SelectedActor = ""
+	g.Inventory.Slots[1].Objects = append(g.Inventory.Slots[1].Objects, "flashlight")
+	g.Actors["reyes"].Room = "Diner"
+	g.Objects["newObject"] = &Object{State: 2}
+
+	dict := g.Dict()
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"easy_mode", dict["easy_mode"], 1},
+		{"gameTime", dict["gameTime"], 4711.5},
+		{"selectedActor", dict["selectedActor"], ""},
+		{"inventory slot", dict["inventory"].(map[string]any)["slots"].([]any)[1], map[string]any{"objects": []any{"flashlight"}}},
+		{"actor", dict["actors"].(map[string]any)["reyes"], map[string]any{"_room": "Diner"}},
+		{"object", dict["objects"].(map[string]any)["newObject"], map[string]any{"_state": 2}},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s was %#v, want: %#v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestGameDictIndependent(t *testing.T) {
+	dict := testSavegameDict()
+	g, err := GameFromDict(dict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dict["globals"].(map[string]any)["g.act"] = 3
+	if got := g.Globals["g.act"]; got != 2 {
+		t.Errorf("global of game after modifying the original dictionary was %v, want: 2", got)
+	}
+	d := g.Dict()
+	d["globals"].(map[string]any)["g.act"] = 4
+	d["dialog"].(map[string]any)["&ray.talkedTo"] = 0
+	if got := g.Globals["g.act"]; got != 2 {
+		t.Errorf("global of game after modifying its dictionary was %v, want: 2", got)
+	}
+	if got := g.Dialog["&ray.talkedTo"]; got != 1 {
+		t.Errorf("dialog state of game after modifying its dictionary was %v, want: 1", got)
+	}
+}
+
+func TestGameFromDictTypeError(t *testing.T) {
+	tests := []struct {
+		dict    map[string]any
+		wantErr string
+	}{
+		{
+			map[string]any{"version": "2"},
+			`savegame key "/version": expected integer, got string`,
+		},
+		{
+			map[string]any{"actors": map[string]any{"ray": map[string]any{"_room": 1}}},
+			`savegame key "/actors/ray/_room": expected string, got int`,
+		},
+		{
+			map[string]any{"inventory": map[string]any{"slots": []any{map[string]any{"objects": []any{"badge", 2}}}}},
+			`savegame key "/inventory/slots/0/objects/1": expected string, got int`,
+		},
+		{
+			map[string]any{"callbacks": map[string]any{"callbacks": []any{"startTrain"}}},
+			`savegame key "/callbacks/callbacks/0": expected dictionary, got string`,
+		},
+	}
+	for _, tt := range tests {
+		_, err := GameFromDict(tt.dict)
+		if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("converting %v: error was %v, want: %s", tt.dict, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package savegame
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fzipp/gg/ggdict"
+)
+
+// Header is the metadata of a savegame.
+type Header struct {
+	// Format is the name of the savegame format, e.g. "thimbleweed".
+	Format string
+	// Version is the savegame version.
+	Version int
+	// SaveBuild is the build number of the game that wrote the savegame.
+	SaveBuild int
+	// SaveTime is the time the game was saved.
+	SaveTime time.Time
+	// GameTime is the played time.
+	GameTime time.Duration
+	// CurrentRoom is the name of the current room.
+	CurrentRoom string
+	// SelectedActor is the name of the selected actor.
+	SelectedActor string
+	// EasyMode is true if the game is played in casual mode.
+	EasyMode bool
+}
+
+var headerKeys = []string{
+	"version", "savebuild", "savetime", "gameTime",
+	"currentRoom", "selectedActor", "easy_mode",
+}
+
+// LoadHeader reads the metadata of a savegame file. The format is
+// detected, see Read.
+func LoadHeader(path string) (*Header, error) {
+	return loadHeader(path, ReadHeader)
+}
+
+// LoadHeader reads the metadata of a savegame file in this format.
+func (f Format) LoadHeader(path string) (*Header, error) {
+	return loadHeader(path, f.ReadHeader)
+}
+
+func loadHeader(path string, read func(io.Reader) (*Header, error)) (*Header, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open savegame file: %w", err)
+	}
+	defer file.Close()
+	return read(file)
+}
+
+// ReadHeader reads the metadata of savegame data. The format is detected,
+// see Read. Since a savegame is encrypted as a whole, all of the data is
+// decrypted, but only the values of the metadata keys are decoded.
+func ReadHeader(r io.Reader) (*Header, error) {
+	return readHeader(r, decrypt)
+}
+
+// ReadHeader reads the metadata of savegame data in this format.
+func (f Format) ReadHeader(r io.Reader) (*Header, error) {
+	return readHeader(r, func(data []byte) ([]byte, Format, error) {
+		return data, f, f.decrypt(data)
+	})
+}
+
+func readHeader(r io.Reader, decrypt func([]byte) ([]byte, Format, error)) (*Header, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not read savegame data: %w", err)
+	}
+	plain, f, err := decrypt(data)
+	if err != nil {
+		return nil, err
+	}
+	dict, err := ggdict.UnmarshalKeys(plain, f.DictFormat, headerKeys...)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal savegame data: %w", err)
+	}
+	return HeaderFromDict(dict, f)
+}
+
+// HeaderFromDict returns the metadata of a savegame dictionary that was
+// read in the given format, e.g. by Decode. It returns an error if a
+// metadata key has a value of an unexpected type.
+func HeaderFromDict(dict map[string]any, f Format) (*Header, error) {
+	d := newDictReader("", dict)
+	h := &Header{
+		Format:        f.Name,
+		Version:       d.int("version"),
+		SaveBuild:     d.int("savebuild"),
+		SaveTime:      time.Unix(int64(d.int("savetime")), 0),
+		GameTime:      time.Duration(d.number("gameTime") * float64(time.Second)),
+		CurrentRoom:   d.string("currentRoom"),
+		SelectedActor: d.string("selectedActor"),
+		EasyMode:      d.int("easy_mode") != 0,
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return h, nil
+}
+
+// Slot is a savegame file in a save directory.
+type Slot struct {
+	// Name is the file name of the savegame without extension,
+	// e.g. "Savegame1".
+	Name string
+	// Path is the path of the savegame file.
+	Path string
+	// Thumbnail is the path of the thumbnail image belonging to the
+	// savegame, or empty if there is none.
+	Thumbnail string
+	// Header is the metadata of the savegame, or nil if it was not
+	// read (see ListDir) or could not be read.
+	Header *Header
+	// Err is the error that occurred reading the metadata, if any.
+	Err error
+}
+
+// ReadDir reads the metadata of all savegame files in a save directory,
+// see ListDir. The format of each savegame is detected, see Read. An error
+// reading a single savegame is reported in its Slot and does not stop the
+// reading of the other savegames.
+func ReadDir(dir string) ([]Slot, error) {
+	return readDir(dir, LoadHeader)
+}
+
+// ReadDir reads the metadata of all savegame files in a save directory in
+// this format, see the package-level ReadDir.
+func (f Format) ReadDir(dir string) ([]Slot, error) {
+	return readDir(dir, f.LoadHeader)
+}
+
+func readDir(dir string, load func(path string) (*Header, error)) ([]Slot, error) {
+	slots, err := ListDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for i := range slots {
+		if slots[i].Err == nil {
+			slots[i].Header, slots[i].Err = load(slots[i].Path)
+		}
+	}
+	return slots, nil
+}
+
+// ListDir returns the savegame files (Savegame*.save) in a save directory,
+// sorted by file name, without reading their metadata. Each savegame is
+// paired with the thumbnail image of the same name with .png extension,
+// if it exists.
+func ListDir(dir string) ([]Slot, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read save directory: %w", err)
+	}
+	var slots []Slot
+	for _, e := range entries {
+		if e.IsDir() || !isSavegameFile(e.Name()) {
+			continue
+		}
+		slots = append(slots, newSlot(filepath.Join(dir, e.Name())))
+	}
+	return slots, nil
+}
+
+// isSavegameFile reports whether a file name is the name of a savegame
+// file of a save slot, e.g. "Savegame1.save".
+func isSavegameFile(name string) bool {
+	return strings.HasPrefix(name, "Savegame") &&
+		strings.EqualFold(filepath.Ext(name), ".save")
+}
+
+// ReadSlot reads the metadata of a savegame file and pairs it with the
+// thumbnail image of the same name with .png extension, if it exists.
+// The format is detected, see Read.
+func ReadSlot(path string) Slot {
+	return readSlot(path, LoadHeader)
+}
+
+// ReadSlot reads the metadata of a savegame file in this format, see the
+// package-level ReadSlot.
+func (f Format) ReadSlot(path string) Slot {
+	return readSlot(path, f.LoadHeader)
+}
+
+func readSlot(path string, load func(path string) (*Header, error)) Slot {
+	slot := newSlot(path)
+	if slot.Err == nil {
+		slot.Header, slot.Err = load(path)
+	}
+	return slot
+}
+
+func newSlot(path string) Slot {
+	ext := filepath.Ext(path)
+	slot := Slot{
+		Name: strings.TrimSuffix(filepath.Base(path), ext),
+		Path: path,
+	}
+	thumbnail := strings.TrimSuffix(path, ext) + ".png"
+	if _, err := os.Stat(thumbnail); err == nil {
+		slot.Thumbnail = thumbnail
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		slot.Err = err
+	}
+	return slot
+}
@@ -0,0 +1,120 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package savegame
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReadDir(t *testing.T) {
+	dir := t.TempDir()
+	err := Save(filepath.Join(dir, "Savegame1.save"), testSavegameDict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "Savegame1.png"), []byte("\x89PNG"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Save(filepath.Join(dir, "Savegame2.save"), map[string]any{
+		"version":   2,
+		"savebuild": 958,
+		"savetime":  1603119600,
+		"gameTime":  90.5,
+		"easy_mode": 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "Savegame3.save"), make([]byte, 64), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "Prefs.json"), []byte("{}"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only files named Savegame*.save belong to save slots.
+	err = Save(filepath.Join(dir, "Backup.save"), testSavegameDict())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listed, err := ListDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, name := range []string{"Savegame1", "Savegame2", "Savegame3"} {
+		if i >= len(listed) || listed[i].Name != name || listed[i].Header != nil {
+			t.Errorf("listed slots were %+v, want: Savegame1, Savegame2 and Savegame3 without headers", listed)
+			break
+		}
+	}
+
+	slots, err := ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(slots) != 3 {
+		t.Fatalf("number of slots was %d, want: 3", len(slots))
+	}
+	want := []Slot{
+		{
+			Name:      "Savegame1",
+			Path:      filepath.Join(dir, "Savegame1.save"),
+			Thumbnail: filepath.Join(dir, "Savegame1.png"),
+			Header: &Header{
+				Format:        "thimbleweed",
+				Version:       2,
+				SaveBuild:     958,
+				SaveTime:      time.Unix(1603116000, 0),
+				GameTime:      4711 * time.Second,
+				CurrentRoom:   "MainStreet",
+				SelectedActor: "ray",
+			},
+		},
+		{
+			Name: "Savegame2",
+			Path: filepath.Join(dir, "Savegame2.save"),
+			Header: &Header{
+				Format:    "thimbleweed",
+				Version:   2,
+				SaveBuild: 958,
+				SaveTime:  time.Unix(1603119600, 0),
+				GameTime:  90500 * time.Millisecond,
+				EasyMode:  true,
+			},
+		},
+	}
+	for i, w := range want {
+		if !reflect.DeepEqual(slots[i], w) {
+			t.Errorf("slot %d was %+v (header %+v), want: %+v (header %+v)", i, slots[i], slots[i].Header, w, w.Header)
+		}
+	}
+	if slots[2].Name != "Savegame3" || slots[2].Err == nil || slots[2].Header != nil {
+		t.Errorf("invalid slot %q was read without error: %+v", slots[2].Name, slots[2])
+	}
+}
+
+func TestFormatReadSlot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Savegame1.save")
+	if err := Save(path, testSavegameDict()); err != nil {
+		t.Fatal(err)
+	}
+	slot := FormatThimbleweed.ReadSlot(path)
+	if slot.Err != nil || slot.Header == nil || slot.Header.CurrentRoom != "MainStreet" {
+		t.Errorf("slot read in format %q was %+v, want: header with current room MainStreet", FormatThimbleweed.Name, slot)
+	}
+	other := FormatThimbleweed
+	other.Name = "other"
+	other.Key[0]++
+	if slot := other.ReadSlot(path); slot.Err == nil {
+		t.Errorf("reading slot in format with a different key returned no error: %+v", slot)
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright 2022 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package savegame
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/fzipp/gg/ggdict"
+)
+
+// savegameGenerator generates random savegame dictionaries with the
+// sections of a Thimbleweed Park savegame. Modelled keys are null in one
+// of eight cases.
+type savegameGenerator struct {
+	rnd *rand.Rand
+}
+
+func newSavegameGenerator(seed int64) *savegameGenerator {
+	return &savegameGenerator{rnd: rand.New(rand.NewSource(seed))}
+}
+
+// savegame generates a savegame dictionary with about n entities
+// (actors, objects and rooms).
+func (g *savegameGenerator) savegame(n int) map[string]any {
+	dict := map[string]any{
+		"version":       g.orNull(2),
+		"savebuild":     g.orNull(g.rnd.Intn(1000)),
+		"savetime":      g.orNull(1600000000 + g.rnd.Intn(100000000)),
+		"gameTime":      g.orNull(g.number()),
+		"gameGUID":      g.orNull(g.name()),
+		"currentRoom":   g.orNull(g.name()),
+		"selectedActor": g.orNull(g.name()),
+		"easy_mode":     g.orNull(g.rnd.Intn(2)),
+		"inputState":    g.orNull(g.rnd.Intn(256)),
+		"actors":        g.orNull(g.entities(n/3, "_room", "_costume")),
+		"objects":       g.orNull(g.entities(n/3, "_state", "_touchable")),
+		"rooms":         g.orNull(g.entities(n / 3)),
+		"globals":       g.orNull(g.vars(n)),
+		"dialog":        g.orNull(g.vars(n / 4)),
+	}
+	slots := make([]any, 1+g.rnd.Intn(4))
+	for i := range slots {
+		objects := make([]any, g.rnd.Intn(12))
+		for j := range objects {
+			objects[j] = g.name()
+		}
+		slots[i] = map[string]any{"objects": g.orNull(objects), "scroll": g.rnd.Intn(3)}
+	}
+	dict["inventory"] = g.orNull(map[string]any{"slots": g.orNull(slots)})
+	callbacks := make([]any, g.rnd.Intn(5))
+	for i := range callbacks {
+		callbacks[i] = map[string]any{
+			"guid":     g.orNull(i),
+			"function": g.orNull(g.name()),
+			"time":     g.orNull(g.number()),
+			"param":    g.value(1),
+		}
+	}
+	dict["callbacks"] = g.orNull(map[string]any{
+		"callbacks": g.orNull(callbacks),
+		"nextGuid":  g.orNull(len(callbacks)),
+	})
+	return dict
+}
+
+func (g *savegameGenerator) entities(n int, engineKeys ...string) map[string]any {
+	entities := make(map[string]any, n)
+	for i := 0; i < n; i++ {
+		e := g.vars(g.rnd.Intn(8))
+		for _, key := range engineKeys {
+			if g.rnd.Intn(4) > 0 {
+				if key == "_state" || key == "_touchable" {
+					e[key] = g.orNull(g.rnd.Intn(4))
+				} else {
+					e[key] = g.orNull(g.name())
+				}
+			}
+		}
+		entities[g.name()] = e
+	}
+	return entities
+}
+
+func (g *savegameGenerator) vars(n int) map[string]any {
+	vars := make(map[string]any, n)
+	for i := 0; i < n; i++ {
+		vars[g.name()] = g.value(2)
+	}
+	return vars
+}
+
+// orNull returns nil in one of eight cases, otherwise v.
+func (g *savegameGenerator) orNull(v any) any {
+	if g.rnd.Intn(8) == 0 {
+		return nil
+	}
+	return v
+}
+
+// value generates a random value, nested up to the given depth.
+func (g *savegameGenerator) value(depth int) any {
+	k := 6
+	if depth > 0 {
+		k = 8
+	}
+	switch g.rnd.Intn(k) {
+	case 0:
+		return nil
+	case 1, 2:
+		return g.rnd.Intn(2000) - 1000
+	case 3:
+		return g.number()
+	case 4, 5:
+		return g.name()
+	case 6:
+		array := make([]any, g.rnd.Intn(4))
+		for i := range array {
+			array[i] = g.value(depth - 1)
+		}
+		return array
+	default:
+		return g.vars(g.rnd.Intn(4))
+	}
+}
+
+// number generates a random float, or an integer in one of four cases.
+func (g *savegameGenerator) number() any {
+	if g.rnd.Intn(4) == 0 {
+		return g.rnd.Intn(100000)
+	}
+	return g.rnd.Float64() * 10000
+}
+
+var nameChars = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ_0123456789 äöü€")
+
+func (g *savegameGenerator) name() string {
+	name := make([]rune, 1+g.rnd.Intn(16))
+	for i := range name {
+		name[i] = nameChars[g.rnd.Intn(len(nameChars))]
+	}
+	return string(name)
+}
+
+func TestGeneratedRoundTrip(t *testing.T) {
+	sizes := []int{0, 1, 10, 100, 1000, 10000}
+	for seed, n := range sizes {
+		t.Run(fmt.Sprintf("entities=%d", n), func(t *testing.T) {
+			dict := newSavegameGenerator(int64(seed)).savegame(n)
+			var buf bytes.Buffer
+			err := Write(&buf, dict)
+			if err != nil {
+				t.Fatalf("writing savegame failed: %v", err)
+			}
+			data := buf.Bytes()
+			f := FormatThimbleweed
+			dictData, err := ggdict.Marshal(dict, f.DictFormat)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantSize := f.MinSize + f.FooterSize
+			if len(dictData) > f.MinSize {
+				wantSize = len(dictData) + f.FooterSize
+			}
+			if len(data) != wantSize {
+				t.Errorf("savegame size was %d, want: %d", len(data), wantSize)
+			}
+			got, format, err := Decode(data)
+			if err != nil {
+				t.Fatalf("decoding savegame failed: %v", err)
+			}
+			if format.Name != f.Name {
+				t.Errorf("detected format was %q, want: %q", format.Name, f.Name)
+			}
+			if !reflect.DeepEqual(got, dict) {
+				t.Errorf("savegame round trip resulted in a different dictionary")
+			}
+
+			g, err := GameFromDict(got)
+			if err != nil {
+				t.Fatalf("converting dictionary to game failed: %v", err)
+			}
+			if !reflect.DeepEqual(g.Dict(), dict) {
+				t.Errorf("game round trip resulted in a different dictionary")
+			}
+
+			var buf2 bytes.Buffer
+			err = Write(&buf2, got)
+			if err != nil {
+				t.Fatalf("writing decoded savegame failed: %v", err)
+			}
+			if len(buf2.Bytes()) != len(data) {
+				t.Errorf("size of rewritten savegame was %d, want: %d", len(buf2.Bytes()), len(data))
+			}
+		})
+	}
+}
+
+func BenchmarkWrite(b *testing.B) {
+	dict := newSavegameGenerator(1).savegame(1000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		err := Write(io.Discard, dict)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
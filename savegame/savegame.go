@@ -9,12 +9,14 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/fzipp/gg/crypt/xxtea"
 	"github.com/fzipp/gg/ggdict"
 )
 
-var key = xxtea.Key{
+// XXTEAKey is the key of the XXTEA encryption of Thimbleweed Park savegames.
+var XXTEAKey = xxtea.Key{
 	0xAEA4EDF3,
 	0xAFF8332A,
 	0xB5A2DBB4,
@@ -23,8 +25,7 @@ var key = xxtea.Key{
 
 var endianness = binary.LittleEndian
 
-const lenFooter = 16
-
+// Load reads a savegame file. The format is detected, see Read.
 func Load(path string) (map[string]any, error) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -34,65 +35,78 @@ func Load(path string) (map[string]any, error) {
 	return Read(f)
 }
 
+// Read reads savegame data. The format is detected by trying each of the
+// known Formats until the checksum of the decrypted data is valid.
 func Read(r io.Reader) (map[string]any, error) {
 	data, err := io.ReadAll(r)
 	if err != nil {
 		return nil, fmt.Errorf("could not read savegame data: %w", err)
 	}
-	decrypted := xxtea.Decrypt(data, key)
-	if !isChecksumOk(decrypted) {
-		return nil, fmt.Errorf("invalid checksum for savegame data")
-	}
-	dict, err := ggdict.Unmarshal(decrypted, ggdict.FormatThimbleweed) // TODO: FormatMonkey?
-	if err != nil {
-		return nil, fmt.Errorf("could not unmarshal savegame data: %w", err)
-	}
-	return dict, nil
+	dict, _, err := Decode(data)
+	return dict, err
 }
 
-func Save(path string, dict map[string]any) error {
-	f, err := os.Create(path)
+// Decode decodes savegame data and returns the dictionary together with
+// the detected format, see Read. The data is not modified.
+func Decode(data []byte) (map[string]any, Format, error) {
+	plain, f, err := decrypt(data)
 	if err != nil {
-		return fmt.Errorf("could not open savegame file: %w", err)
+		return nil, Format{}, err
 	}
-	defer f.Close()
-	return Write(f, dict)
-}
-
-func Write(w io.Writer, dict map[string]any) error {
-	data := ggdict.Marshal(dict, ggdict.FormatThimbleweed) // TODO: FormatMonkey?
-	data = zeroPad(data, 500_000)
-	sum := checksum(data)
-	footerBytes := make([]byte, lenFooter)
-	endianness.PutUint32(footerBytes, sum)
-	data = append(data, footerBytes...)
-	encrypted := xxtea.Encrypt(data, key)
-	_, err := w.Write(encrypted)
+	dict, err := ggdict.Unmarshal(plain, f.DictFormat)
 	if err != nil {
-		return fmt.Errorf("could not write savegame data: %w", err)
+		return nil, Format{}, fmt.Errorf("could not unmarshal savegame data: %w", err)
 	}
-	return nil
+	return dict, f, nil
 }
 
-func zeroPad(data []byte, minLen int) []byte {
-	if len(data) >= minLen {
-		return data
+// decrypt returns a decrypted copy of the data, decrypted with the first
+// of the known Formats for which the checksum is valid.
+func decrypt(data []byte) ([]byte, Format, error) {
+	var errs []string
+	buf := make([]byte, len(data))
+	for _, f := range Formats {
+		copy(buf, data)
+		err := f.decrypt(buf)
+		if err == nil {
+			return buf, f, nil
+		}
+		if len(Formats) == 1 {
+			return nil, Format{}, err
+		}
+		errs = append(errs, f.Name+": "+err.Error())
 	}
-	lenPadding := minLen - len(data)
-	return append(data, make([]byte, lenPadding)...)
+	return nil, Format{}, fmt.Errorf("unknown savegame format (%s)", strings.Join(errs, "; "))
 }
 
-func isChecksumOk(data []byte) bool {
-	checksumIndex := len(data) - lenFooter
-	sumGot := checksum(data[:checksumIndex])
-	sumWant := endianness.Uint32(data[checksumIndex:])
-	return sumGot == sumWant
+// Save writes a savegame file in the Thimbleweed Park format.
+func Save(path string, dict map[string]any) error {
+	return FormatThimbleweed.Save(path, dict)
 }
 
-func checksum(data []byte) uint32 {
-	sum := uint32(0x6583463)
-	for _, b := range data {
-		sum += uint32(b)
+// Write writes savegame data in the Thimbleweed Park format.
+func Write(w io.Writer, dict map[string]any) error {
+	return FormatThimbleweed.Write(w, dict)
+}
+
+// zeroPadWithFooter pads the data with zeros to at least minLen bytes and
+// appends a zeroed footer. It reuses the capacity of data if it is large
+// enough, otherwise it allocates once.
+func zeroPadWithFooter(data []byte, minLen, lenFooter int) []byte {
+	n := len(data)
+	if n < minLen {
+		n = minLen
+	}
+	n += lenFooter
+	if cap(data) < n {
+		padded := make([]byte, n)
+		copy(padded, data)
+		return padded
+	}
+	padded := data[:n]
+	tail := padded[len(data):]
+	for i := range tail {
+		tail[i] = 0
 	}
-	return sum
+	return padded
 }
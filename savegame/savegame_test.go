@@ -9,7 +9,7 @@ import (
 	"testing"
 )
 
-func TestZeroPad(t *testing.T) {
+func TestZeroPadWithFooter(t *testing.T) {
 	tests := []struct {
 		data   []byte
 		minLen int
@@ -23,10 +23,11 @@ func TestZeroPad(t *testing.T) {
 		{[]byte("hello, world"), 10, []byte("hello, world")},
 	}
 	for _, tt := range tests {
-		got := zeroPad(tt.data, tt.minLen)
-		if !reflect.DeepEqual(got, tt.want) {
+		got := zeroPadWithFooter(tt.data, tt.minLen, 16)
+		want := append(tt.want, make([]byte, 16)...)
+		if !reflect.DeepEqual(got, want) {
 			t.Errorf("zero padding for %q with minimum length %d - got: %q, want: %q",
-				tt.data, tt.minLen, got, tt.want)
+				tt.data, tt.minLen, got, want)
 		}
 	}
 }
@@ -47,7 +48,7 @@ func TestIsChecksumOk(t *testing.T) {
 		{[]byte("hello, world\xeb\x38\x58\x07\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"), false},
 	}
 	for _, tt := range tests {
-		got := isChecksumOk(tt.data)
+		got := FormatThimbleweed.isChecksumOk(tt.data)
 		if got != tt.want {
 			t.Errorf("checksum ok for %q? got: %v, want: %v",
 				tt.data, got, tt.want)
@@ -67,7 +68,7 @@ func TestChecksum(t *testing.T) {
 		{[]byte("hello, world"), 0x065838eb},
 	}
 	for _, tt := range tests {
-		got := checksum(tt.data)
+		got := FormatThimbleweed.checksum(tt.data)
 		if got != tt.want {
 			t.Errorf("checksum for %q - got: %#08x, want: %#08x",
 				tt.data, got, tt.want)
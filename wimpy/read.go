@@ -20,7 +20,11 @@ func Read(r io.Reader) (*Room, error) {
 	if err != nil {
 		return nil, fmt.Errorf("could not read wimpy data: %w", err)
 	}
-	dict, err := ggdict.Unmarshal(buf.Bytes(), ggdict.FormatThimbleweed) // TODO: FormatMonkey?
+	format, err := ggdict.DetectFormat(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("could not detect wimpy dictionary format: %w", err)
+	}
+	dict, err := ggdict.Unmarshal(buf.Bytes(), format)
 	if err != nil {
 		return nil, fmt.Errorf("could not unmarshal wimpy dictionary: %w", err)
 	}
@@ -80,11 +84,11 @@ func dictToRoom(dict map[string]any) (r *Room, err error) {
 		obj.Name = objDict["name"].(string)
 		obj.Parent = optionalString(objDict["parent"])
 		obj.Animations = readAnimations(objDict["animations"])
-		obj.HotSpot, err = parseRectangle(objDict["hotspot"].(string))
+		obj.HotSpot, err = parseRectangle(coordinateString(objDict["hotspot"]))
 		if err != nil {
 			return nil, fmt.Errorf("room %q, object %q [%d]: invalid hotspot rectangle", r.Name, obj.Name, i)
 		}
-		obj.Pos, err = parsePoint(objDict["pos"].(string))
+		obj.Pos, err = parsePoint(coordinateString(objDict["pos"]))
 		if err != nil {
 			return nil, fmt.Errorf("room %q, object %q [%d]: invalid pos", r.Name, obj.Name, i)
 		}
@@ -92,7 +96,7 @@ func dictToRoom(dict map[string]any) (r *Room, err error) {
 		if err != nil {
 			return nil, fmt.Errorf("room %q, object %q [%d]: invalid usedir", r.Name, obj.Name, i)
 		}
-		obj.UsePos, err = parsePoint(objDict["usepos"].(string))
+		obj.UsePos, err = parsePoint(coordinateString(objDict["usepos"]))
 		if err != nil {
 			return nil, fmt.Errorf("room %q, object %q [%d]: invalid usepos", r.Name, obj.Name, i)
 		}
@@ -102,7 +106,7 @@ func dictToRoom(dict map[string]any) (r *Room, err error) {
 		obj.Trigger = optionalBool(objDict["trigger"])
 		r.Objects[i] = obj
 	}
-	r.RoomSize, err = parsePoint(dict["roomsize"].(string))
+	r.RoomSize, err = parsePoint(coordinateString(dict["roomsize"]))
 	if err != nil {
 		return nil, fmt.Errorf("room %q: invalid roomsize", r.Name)
 	}
@@ -136,7 +140,7 @@ func dictToRoom(dict map[string]any) (r *Room, err error) {
 	for i, boxDict := range walkboxes {
 		box := WalkBox{}
 		box.Name = optionalString(boxDict["name"])
-		box.Polygon, err = parsePolygon(boxDict["polygon"].(string))
+		box.Polygon, err = parsePolygon(coordinateString(boxDict["polygon"]))
 		if err != nil {
 			return nil, fmt.Errorf("room %q, walkbox %q [%d]: invalid polygon", r.Name, box.Name, i)
 		}
@@ -190,6 +194,21 @@ func optionalString(x any) string {
 	return x.(string)
 }
 
+// coordinateString returns the string representation of a value that is
+// stored as a string in Thimbleweed Park and as one of the coordinate types
+// in Return to Monkey Island.
+func coordinateString(x any) string {
+	switch c := x.(type) {
+	case ggdict.Coordinate:
+		return string(c)
+	case ggdict.CoordinatePair:
+		return string(c)
+	case ggdict.CoordinateList:
+		return string(c)
+	}
+	return x.(string)
+}
+
 func optionalDicts(x any) []map[string]any {
 	xs := optionalSlice(x)
 	slice := make([]map[string]any, len(xs))
@@ -15,7 +15,11 @@ import (
 )
 
 func Write(w io.Writer, r *Room) (n int, err error) {
-	return w.Write(ggdict.Marshal(roomToDict(r), ggdict.FormatThimbleweed)) // TODO: FormatMonkey?
+	data, err := ggdict.Marshal(roomToDict(r), ggdict.FormatThimbleweed) // TODO: FormatMonkey?
+	if err != nil {
+		return 0, fmt.Errorf("could not marshal wimpy dictionary: %w", err)
+	}
+	return w.Write(data)
 }
 
 func roomToDict(r *Room) map[string]any {